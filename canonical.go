@@ -0,0 +1,141 @@
+package yquery
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CanonicalizeConfig is the optional parameter for Canonicalize
+type CanonicalizeConfig struct {
+	// Delimiter is the custom delimiter used in Paths
+	Delimiter string
+	// Paths are parser strings of items whose keys are sorted, recursively
+	// Keys of the whole document are sorted if it is empty.
+	Paths []string
+	// KeyOrder are keys put before others in the same order, e.g. []string{"apiVersion", "kind", "metadata"}
+	// Other keys are sorted alphabetically after them.
+	KeyOrder []string
+	// KeepStyle could be set to true to keep styles of scalars, mappings and sequences
+	KeepStyle bool
+	// Explode could be set to true to explode the document before sorting, see Explode
+	Explode bool
+}
+
+// Canonicalize sort mapping keys and normalize styles, so that documents with the same data are written in the same way
+// Merge keys are put first in the mapping, since they are usually treated as the base of the mapping.
+// Scalars are written in plain style unless quote is needed, mappings and sequences are written in block style,
+// and tags which could be resolved implicitly are removed.
+// Comments and anchors are kept, and move with their items. An anchor sorted after its references is swapped with the first reference.
+// Indentation is always normalized by Marshal.
+func (y *YQuery) Canonicalize(config ...CanonicalizeConfig) error {
+	if len(config) > 1 {
+		return fmt.Errorf("canonicalize could only get 0 or 1 config, got %d", len(config))
+	}
+	c := CanonicalizeConfig{}
+	if len(config) == 1 {
+		c = config[0]
+	}
+	return y.modify(Operation{Name: "Canonicalize", Paths: c.Paths}, func() error {
+		delimiter, err := getDelimiter([]Config{{Delimiter: c.Delimiter}})
+		if err != nil {
+			return err
+		}
+		root := y.RootNode
+		if c.Explode {
+			if root, err = explodeNode(root, nil); err != nil {
+				return err
+			}
+		}
+		nodes := []*yaml.Node{root}
+		if len(c.Paths) > 0 {
+			nodes = nil
+		}
+		s := &YQuery{RootNode: root}
+		for _, parser := range c.Paths {
+			node, err := s.locateNode(getParserSlice(parser, delimiter), delimiter)
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, node)
+		}
+		priority := map[string]int{}
+		for i, key := range c.KeyOrder {
+			priority[key] = i - len(c.KeyOrder)
+		}
+		for _, node := range nodes {
+			walkNode(node, func(n *yaml.Node) {
+				if n.Kind == yaml.MappingNode {
+					sortMapping(n, priority)
+				}
+			})
+		}
+		if !c.KeepStyle {
+			walkNode(root, normalizeStyle)
+		}
+		orderAnchors(root)
+		y.RootNode = root
+		return nil
+	})
+}
+
+// mappingItem is a key and value pair of mapping
+type mappingItem struct {
+	key   *yaml.Node
+	value *yaml.Node
+}
+
+// sortMapping sort items of mapping by key
+// Merge keys come first, then keys in priority (which are negative), then others alphabetically.
+func sortMapping(mapping *yaml.Node, priority map[string]int) {
+	items := make([]mappingItem, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		items = append(items, mappingItem{mapping.Content[i], mapping.Content[i+1]})
+	}
+	rank := func(key *yaml.Node) int {
+		if key.Tag == mergeTag {
+			return -len(priority) - 1
+		}
+		return priority[key.Value]
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		rankI, rankJ := rank(items[i].key), rank(items[j].key)
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+		return rankI == 0 && items[i].key.Value < items[j].key.Value
+	})
+	content := make([]*yaml.Node, 0, len(mapping.Content))
+	for _, item := range items {
+		content = append(content, item.key, item.value)
+	}
+	mapping.Content = content
+}
+
+// normalizeStyle clear the style of node, and tag if it could be resolved implicitly
+func normalizeStyle(node *yaml.Node) {
+	if node.Kind == yaml.AliasNode || node.Kind == yaml.DocumentNode {
+		return
+	}
+	tagged := node.Style&yaml.TaggedStyle != 0
+	node.Style = 0
+	if tagged && node.Tag != "" && node.ShortTag() != implicitTag(node) {
+		node.Style = yaml.TaggedStyle
+	}
+}
+
+// implicitTag return the tag of node when it is written without tag
+func implicitTag(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return mapTag
+	case yaml.SequenceNode:
+		return seqTag
+	}
+	if node.ShortTag() == strTag {
+		// a string is quoted if needed, instead of being tagged
+		return strTag
+	}
+	return (&yaml.Node{Kind: yaml.ScalarNode, Value: node.Value}).ShortTag()
+}
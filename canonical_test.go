@@ -0,0 +1,106 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var canonicalData = `metadata:
+  name: 'web'
+  labels: {tier: "front", app: web}
+kind: Deployment
+# comment of base
+base: &base
+  z: !!str 1
+  y: !!float 1
+  x: !custom value
+apiVersion: apps/v1
+spec:
+  b: |
+    literal
+  <<: *base
+  a: ["1", 2]
+`
+
+func TestCanonicalize(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(canonicalData))
+	asserts.NoError(yq.Canonicalize(yquery.CanonicalizeConfig{
+		KeyOrder: []string{"apiVersion", "kind", "metadata"},
+	}))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	// language=yaml
+	asserts.Equal(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+    labels:
+        app: web
+        tier: front
+    name: web
+# comment of base
+base: &base
+    x: !custom value
+    y: !!float 1
+    z: "1"
+spec:
+    <<: *base
+    a:
+        - "1"
+        - 2
+    b: |
+        literal
+`, string(out))
+
+	v, _ := yq.Get("spec.z")
+	asserts.Equal("1", v)
+}
+
+func TestCanonicalizeAnchorOrder(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte("z: &a {k: 1}\nb:\n  <<: *a\na: *a\n"))
+	asserts.NoError(yq.Canonicalize())
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("a: &a\n    k: 1\nb:\n    <<: *a\nz: *a\n", string(out))
+	_, err = yquery.Unmarshal(out)
+	asserts.NoError(err)
+}
+
+func TestCanonicalizeConfig(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(canonicalData))
+	asserts.NoError(yq.Canonicalize(yquery.CanonicalizeConfig{
+		Paths:     []string{"metadata"},
+		KeepStyle: true,
+		Explode:   true,
+	}))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	// language=yaml
+	asserts.Equal(`metadata:
+    labels: {app: web, tier: "front"}
+    name: 'web'
+kind: Deployment
+# comment of base
+base:
+    z: !!str 1
+    y: !!float 1
+    x: !custom value
+apiVersion: apps/v1
+spec:
+    b: |
+        literal
+    z: !!str 1
+    y: !!float 1
+    x: !custom value
+    a: ["1", 2]
+`, string(out))
+
+	asserts.Error(yq.Canonicalize(yquery.CanonicalizeConfig{Paths: []string{"missing"}}))
+	asserts.Error(yq.Canonicalize(yquery.CanonicalizeConfig{}, yquery.CanonicalizeConfig{}))
+}
@@ -0,0 +1,51 @@
+// Command yquery-merge is a git merge driver for yaml files, which merges them by node instead of by line
+// Configure it in git with
+//     git config merge.yquery.name "yaml merge by yquery"
+//     git config merge.yquery.driver "yquery-merge %O %A %B"
+//     echo "*.yaml merge=yquery" >> .gitattributes
+//
+// The merged document is written to the file of ours (%A).
+// Conflicts are marked with comments above the conflicted items, and it exits with 1 so that git reports the conflict.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+func main() {
+	if len(os.Args) != 4 {
+		fmt.Fprintln(os.Stderr, "usage: yquery-merge <base> <ours> <theirs>")
+		os.Exit(2)
+	}
+	conflicts, err := merge(os.Args[1], os.Args[2], os.Args[3])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "yquery-merge:", err)
+		os.Exit(2)
+	}
+	if len(conflicts) > 0 {
+		for _, conflict := range conflicts {
+			fmt.Fprintf(os.Stderr, "CONFLICT (content): %s in %s\n", conflict.Path, os.Args[2])
+		}
+		os.Exit(1)
+	}
+}
+
+// merge merge base, ours and theirs files, and write the result to ours
+func merge(basePath, oursPath, theirsPath string) (yquery.Conflicts, error) {
+	var documents []*yquery.YQuery
+	for _, path := range []string{basePath, oursPath, theirsPath} {
+		document, err := yquery.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	merged, conflicts, err := yquery.Merge3(documents[0], documents[1], documents[2], yquery.Merge3Config{Markers: true})
+	if err != nil {
+		return nil, err
+	}
+	return conflicts, merged.SaveAs(oursPath)
+}
@@ -0,0 +1,147 @@
+package yquery
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommentKind is the position of a comment
+type CommentKind int
+
+const (
+	// HeadComment is the comment in the lines preceding the value
+	HeadComment CommentKind = iota
+	// LineComment is the comment at the end of the line where the value is in
+	LineComment
+	// FootComment is the comment following the value and before empty lines
+	FootComment
+	// KeyHeadComment is the comment in the lines preceding the key of a mapping item
+	KeyHeadComment
+	// KeyLineComment is the comment at the end of the line where the key of a mapping item is in
+	KeyLineComment
+	// KeyFootComment is the comment following the key of a mapping item and before empty lines
+	KeyFootComment
+)
+
+// Comment holds comments of an item
+// Text is stored without the leading "# ", lines are separated by "\n".
+// go-yaml v3 puts comments of a mapping item like following
+//     # KeyHead
+//     key: value # Line
+//     # KeyFoot
+//
+// Comments of sequence item are all on value.
+type Comment struct {
+	Head    string
+	Line    string
+	Foot    string
+	KeyHead string
+	KeyLine string
+	KeyFoot string
+}
+
+// GetComment return comments of the item of parser string
+// Anchor reference and merge item are followed, same as Get.
+func (y *YQuery) GetComment(parser string, config ...Config) (Comment, error) {
+	delimiter, err := getDelimiter(config)
+	if err != nil {
+		return Comment{}, err
+	}
+	parent, index, err := y.locate(getParserSlice(parser, delimiter), delimiter, true)
+	if err != nil {
+		return Comment{}, err
+	}
+	comment := Comment{}
+	value := parent.Content[index]
+	comment.Head = parseComment(value.HeadComment)
+	comment.Line = parseComment(value.LineComment)
+	comment.Foot = parseComment(value.FootComment)
+	if parent.Kind == yaml.MappingNode {
+		key := parent.Content[index-1]
+		comment.KeyHead = parseComment(key.HeadComment)
+		comment.KeyLine = parseComment(key.LineComment)
+		comment.KeyFoot = parseComment(key.FootComment)
+	}
+	return comment, nil
+}
+
+// SetComment set comment of the item of parser string, comment is removed if text is empty
+// Text could be multiple lines, "# " is added to each line if it does not start with "#".
+// Key comments are only available for mapping item.
+func (y *YQuery) SetComment(parser string, kind CommentKind, text string, config ...Config) error {
+	return y.modify(Operation{Name: "SetComment", Paths: []string{parser}}, func() error {
+		delimiter, err := getDelimiter(config)
+		if err != nil {
+			return err
+		}
+		parent, index, err := y.locate(getParserSlice(parser, delimiter), delimiter, false)
+		if err != nil {
+			return err
+		}
+		node := parent.Content[index]
+		if kind >= KeyHeadComment {
+			if parent.Kind != yaml.MappingNode {
+				return fmt.Errorf("the item '%s' is not a mapping item, it has no key", parser)
+			}
+			node = parent.Content[index-1]
+		}
+		switch kind {
+		case HeadComment, KeyHeadComment:
+			node.HeadComment = formatComment(text)
+		case LineComment, KeyLineComment:
+			node.LineComment = formatComment(text)
+		case FootComment, KeyFootComment:
+			node.FootComment = formatComment(text)
+		default:
+			return fmt.Errorf("unknown comment kind %d", kind)
+		}
+		return nil
+	})
+}
+
+// setComment write non empty comments to key and value
+// key could be nil for sequence item.
+func setComment(key *yaml.Node, value *yaml.Node, comment Comment) {
+	setIfNotEmpty := func(comment *string, text string) {
+		if text != "" {
+			*comment = formatComment(text)
+		}
+	}
+	setIfNotEmpty(&value.HeadComment, comment.Head)
+	setIfNotEmpty(&value.LineComment, comment.Line)
+	setIfNotEmpty(&value.FootComment, comment.Foot)
+	if key != nil {
+		setIfNotEmpty(&key.HeadComment, comment.KeyHead)
+		setIfNotEmpty(&key.LineComment, comment.KeyLine)
+		setIfNotEmpty(&key.FootComment, comment.KeyFoot)
+	}
+}
+
+// formatComment add "# " to each line of text
+func formatComment(text string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			lines[i] = "# " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseComment remove "# " from each line of comment
+func parseComment(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimPrefix(line, "#")
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
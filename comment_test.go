@@ -0,0 +1,71 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var commentData = `# head of a
+a: value of a # line of a
+b:
+  - item 0 # line of item 0
+  - item 1
+c: &c
+  # head of d
+  d: value of d
+e:
+  <<: *c
+`
+
+func TestGetComment(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(commentData))
+	comment, err := yq.GetComment("a")
+	asserts.NoError(err)
+	asserts.Equal(yquery.Comment{KeyHead: "head of a", Line: "line of a"}, comment)
+	comment, err = yq.GetComment("b[0]")
+	asserts.NoError(err)
+	asserts.Equal(yquery.Comment{Line: "line of item 0"}, comment)
+	comment, err = yq.GetComment("e.d")
+	asserts.NoError(err)
+	asserts.Equal("head of d", comment.KeyHead)
+	_, err = yq.GetComment("notExist")
+	asserts.Error(err)
+}
+
+func TestSetComment(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(commentData))
+	asserts.NoError(yq.SetComment("a", yquery.KeyHeadComment, "new head\nsecond line"))
+	asserts.NoError(yq.SetComment("a", yquery.LineComment, ""))
+	asserts.NoError(yq.SetComment("b[1]", yquery.LineComment, "# line of item 1"))
+	asserts.Error(yq.SetComment("b[1]", yquery.KeyHeadComment, "no key"))
+	asserts.Error(yq.SetComment("e.d", yquery.LineComment, "in merge"))
+	comment, _ := yq.GetComment("a")
+	asserts.Equal(yquery.Comment{KeyHead: "new head\nsecond line"}, comment)
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Contains(string(out), "# new head\n# second line\na: value of a\n")
+	asserts.Contains(string(out), "- item 1 # line of item 1\n")
+}
+
+func TestSetWithComment(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(commentData))
+	err := yq.Set("f", "new value", yquery.Config{
+		Comment: yquery.Comment{KeyHead: "explain f", Line: "line of f"},
+	})
+	asserts.NoError(err)
+	err = yq.Set("a", "new a", yquery.Config{
+		Comment: yquery.Comment{Line: "new line of a"},
+	})
+	asserts.NoError(err)
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Contains(string(out), "# explain f\nf: new value # line of f\n")
+	asserts.Contains(string(out), "# head of a\na: new a # new line of a\n")
+}
@@ -0,0 +1,217 @@
+package yquery
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CSVOptions is the optional parameter for MarshalCSV and UnmarshalCSV
+type CSVOptions struct {
+	// Comma is the field separator, default is ','. Set it to '\t' for TSV
+	Comma rune
+	// Delimiter is the custom delimiter of column paths
+	Delimiter string
+	// Columns are paths of values in each item, e.g. "name" or "address.city"
+	// By default MarshalCSV use all leaf paths of items, in the order they first appear,
+	// and UnmarshalCSV use the header row.
+	Columns []string
+	// Header are names written in the header row of MarshalCSV, default is the columns
+	Header []string
+	// NoHeader could be set to true to skip the header row
+	// UnmarshalCSV requires Columns with it.
+	NoHeader bool
+}
+
+func getCSVOptions(options []CSVOptions) (CSVOptions, error) {
+	if len(options) > 1 {
+		return CSVOptions{}, fmt.Errorf("csv could only get 0 or 1 options, got %d", len(options))
+	}
+	o := CSVOptions{}
+	if len(options) == 1 {
+		o = options[0]
+	}
+	if o.Comma == 0 {
+		o.Comma = ','
+	}
+	delimiter, err := getDelimiter([]Config{{Delimiter: o.Delimiter}})
+	if err != nil {
+		return o, err
+	}
+	o.Delimiter = delimiter
+	return o, nil
+}
+
+// MarshalCSV write the sequence of parser as CSV, each item in a row
+//     hosts:
+//       - name: web
+//         port: 80
+//
+// MarshalCSV("hosts") results
+//     name,port
+//     web,80
+//
+// Unlike Get, an empty parser string is not an error, it writes the root, which must be a sequence.
+// Anchor references and merge keys are resolved.
+// Missing values and null are written as empty cells, mappings and sequences are written as flow yaml, e.g. "[a, b]".
+func (y *YQuery) MarshalCSV(parser string, options ...CSVOptions) ([]byte, error) {
+	o, err := getCSVOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	root, err := explodeNode(y.RootNode, nil)
+	if err != nil {
+		return nil, err
+	}
+	exploded := &YQuery{RootNode: root}
+	node := root
+	if parser != "" {
+		if node, err = exploded.GetNode(parser, false, Config{Delimiter: o.Delimiter}); err != nil {
+			return nil, err
+		}
+	}
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("the item '%s' is not a sequence", parser)
+	}
+	columns := o.Columns
+	if columns == nil {
+		if columns, err = csvColumns(node, o.Delimiter); err != nil {
+			return nil, err
+		}
+	}
+	header := o.Header
+	if header == nil {
+		header = columns
+	}
+	if len(header) != len(columns) {
+		return nil, fmt.Errorf("header has %d names, but there are %d columns", len(header), len(columns))
+	}
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Comma = o.Comma
+	if !o.NoHeader {
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range node.Content {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			value, err := (&YQuery{RootNode: item}).GetNode(column, false, Config{Delimiter: o.Delimiter})
+			if err != nil {
+				// missing value
+				continue
+			}
+			if row[i], err = csvCell(value); err != nil {
+				return nil, fmt.Errorf("cannot write column '%s': %s", column, err)
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+// csvColumns return leaf paths of mapping items in sequence, in the order they first appear
+func csvColumns(sequence *yaml.Node, delimiter string) ([]string, error) {
+	var columns []string
+	found := map[string]bool{}
+	for i, item := range sequence.Content {
+		if item.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("item [%d] is %s, columns are required for items which are not mapping", i, item.ShortTag())
+		}
+		f := flattener{options: FlattenOptions{Delimiter: delimiter}}
+		if err := f.flatten("", item); err != nil {
+			return nil, err
+		}
+		for _, leaf := range f.items {
+			if !found[leaf.Path] {
+				found[leaf.Path] = true
+				columns = append(columns, leaf.Path)
+			}
+		}
+	}
+	return columns, nil
+}
+
+// csvCell write node as the text of a cell
+func csvCell(node *yaml.Node) (string, error) {
+	switch {
+	case node.Kind == yaml.ScalarNode && node.ShortTag() == nullTag:
+		return "", nil
+	case node.Kind == yaml.ScalarNode:
+		return node.Value, nil
+	}
+	node = cloneNode(node)
+	walkNode(node, func(n *yaml.Node) {
+		n.HeadComment, n.LineComment, n.FootComment = "", "", ""
+		n.Style = n.Style&yaml.TaggedStyle | yaml.FlowStyle
+	})
+	out, err := yaml.Marshal(node)
+	return strings.TrimSuffix(string(out), "\n"), err
+}
+
+// UnmarshalCSV read CSV rows into a sequence of mappings, the root of the document returned
+// Columns are paths of values in each item, e.g. a column "address.city" creates a nested mapping.
+// Types of values are inferred as yaml plain scalars, e.g. "80" is an int, "true" is a bool,
+// cells starting with "[" or "{" are parsed as flow yaml. Empty cells are skipped.
+func UnmarshalCSV(in []byte, options ...CSVOptions) (*YQuery, error) {
+	o, err := getCSVOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(in))
+	reader.Comma = o.Comma
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %s", err)
+	}
+	columns := o.Columns
+	if !o.NoHeader && len(rows) > 0 {
+		if columns == nil {
+			columns = rows[0]
+		}
+		rows = rows[1:]
+	}
+	if columns == nil {
+		return nil, fmt.Errorf("columns are required without header row")
+	}
+	root := &yaml.Node{Kind: yaml.SequenceNode, Tag: seqTag}
+	for i, row := range rows {
+		item := &YQuery{RootNode: &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag}}
+		for j, cell := range row {
+			if cell == "" || j >= len(columns) {
+				continue
+			}
+			value, err := csvValue(cell)
+			if err != nil {
+				return nil, err
+			}
+			config := Config{Delimiter: o.Delimiter, Recursive: true, PadSequence: true}
+			if err := item.Set(columns[j], value, config); err != nil {
+				return nil, fmt.Errorf("cannot set column '%s' of row %d: %s", columns[j], i+1, err)
+			}
+		}
+		root.Content = append(root.Content, item.RootNode)
+	}
+	return &YQuery{RootNode: root, document: yaml.Node{Kind: yaml.DocumentNode}}, nil
+}
+
+// csvValue return the value to Set for a cell
+func csvValue(cell string) (string, error) {
+	if strings.HasPrefix(cell, "[") || strings.HasPrefix(cell, "{") {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(cell), &node); err == nil {
+			return cell, nil
+		}
+	}
+	// resolve the tag as plain scalar, and quote it if needed
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: cell}
+	node.Tag = node.ShortTag()
+	return flatValue(node)
+}
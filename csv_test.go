@@ -0,0 +1,93 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var csvData = `
+defaults: &defaults
+  port: 22
+hosts:
+  - name: web
+    <<: *defaults
+    address:
+      city: Tokyo
+    tags: [a, b]
+  - name: db, primary
+    port: 5432
+    backup: null
+    admin: true
+`
+
+func TestMarshalCSV(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(csvData))
+	out, err := yq.MarshalCSV("hosts")
+	asserts.NoError(err)
+	asserts.Equal(`name,port,address.city,tags[0],tags[1],backup,admin
+web,22,Tokyo,a,b,,
+"db, primary",5432,,,,,true
+`, string(out))
+
+	out, err = yq.MarshalCSV("hosts", yquery.CSVOptions{
+		Comma:   '\t',
+		Columns: []string{"name", "tags"},
+		Header:  []string{"NAME", "TAGS"},
+	})
+	asserts.NoError(err)
+	asserts.Equal("NAME\tTAGS\nweb\t[a, b]\ndb, primary\t\n", string(out))
+
+	out, err = yq.MarshalCSV("hosts", yquery.CSVOptions{Columns: []string{"name"}, NoHeader: true})
+	asserts.NoError(err)
+	asserts.Equal("web\n\"db, primary\"\n", string(out))
+
+	_, err = yq.MarshalCSV("defaults")
+	asserts.Error(err)
+	_, err = yq.MarshalCSV("hosts", yquery.CSVOptions{Columns: []string{"name"}, Header: []string{}})
+	asserts.Error(err)
+}
+
+func TestUnmarshalCSV(t *testing.T) {
+	asserts := assert.New(t)
+	yq, err := yquery.UnmarshalCSV([]byte(`name,port,address.city,tags,note
+web,22,Tokyo,"[a, b]",
+db,5432,,,"key: value"
+"007",1.5,,,true
+`))
+	asserts.NoError(err)
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal(`- name: web
+  port: 22
+  address:
+    city: Tokyo
+  tags: [a, b]
+- name: db
+  port: 5432
+  note: 'key: value'
+- name: 007
+  port: 1.5
+  note: true
+`, string(out))
+
+	csv, err := yq.MarshalCSV("", yquery.CSVOptions{Columns: []string{"name", "port"}})
+	asserts.NoError(err)
+	asserts.Equal("name,port\nweb,22\ndb,5432\n007,1.5\n", string(csv))
+
+	tsv, err := yquery.UnmarshalCSV([]byte("a\t1\n"), yquery.CSVOptions{Comma: '\t', NoHeader: true, Columns: []string{"name", "id"}})
+	asserts.NoError(err)
+	res, _ := tsv.Get("[0].id")
+	asserts.Equal("1", res)
+
+	_, err = yquery.UnmarshalCSV([]byte("a\n"), yquery.CSVOptions{NoHeader: true})
+	asserts.Error(err)
+	_, err = yquery.UnmarshalCSV([]byte("a,b\n\"c\n"))
+	asserts.Error(err)
+	_, err = yquery.UnmarshalCSV([]byte("a[99999999]\nx\n"))
+	asserts.Error(err)
+}
@@ -0,0 +1,90 @@
+package yquery
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Delete remove the item of parser string
+// Mapping item is removed with its key, and sequence items after it move forward.
+// It cannot delete item inside an anchor reference or item comes from a merge key,
+// and it returns an error if the item defines an anchor still referenced by other item.
+func (y *YQuery) Delete(parser string, config ...Config) error {
+	return y.modify(Operation{Name: "Delete", Paths: []string{parser}}, func() error {
+		delimiter, err := getDelimiter(config)
+		if err != nil {
+			return err
+		}
+		return y.deleteNode(getParserSlice(parser, delimiter), delimiter)
+	})
+}
+
+func (y *YQuery) deleteNode(slices []string, delimiter string) error {
+	parent, index, err := y.locate(slices, delimiter, false)
+	if err != nil {
+		return err
+	}
+	if anchor := referencedAnchor(y.RootNode, parent.Content[index]); anchor != "" {
+		return fmt.Errorf("the item '%s' defines anchor '%s' which is still referenced", strings.Join(slices, delimiter), anchor)
+	}
+	removeContent(parent, index)
+	return nil
+}
+
+// referencedAnchor return the name of anchor defined inside node, and referenced outside node
+func referencedAnchor(root *yaml.Node, node *yaml.Node) string {
+	inside := map[*yaml.Node]bool{}
+	walkNode(node, func(n *yaml.Node) {
+		inside[n] = true
+	})
+	anchor := ""
+	walkNode(root, func(n *yaml.Node) {
+		if anchor == "" && n.Alias != nil && inside[n.Alias] && !inside[n] {
+			anchor = n.Alias.Anchor
+		}
+	})
+	return anchor
+}
+
+// Move remove the item of from, and put it to the position of to
+// Like Delete, it cannot move item inside an anchor reference or item comes from a merge key.
+// If to is a mapping item, the existing one is replaced;
+// if to is a sequence item, it is inserted before the existing one, index equals to the length appends it.
+// The parent of to should exist. An anchor moved after its references is swapped with the first reference.
+func (y *YQuery) Move(from string, to string, config ...Config) error {
+	return y.modify(Operation{Name: "Move", Paths: []string{from, to}}, func() error {
+		delimiter, err := getDelimiter(config)
+		if err != nil {
+			return err
+		}
+		return y.moveNode(getParserSlice(from, delimiter), getParserSlice(to, delimiter), delimiter)
+	})
+}
+
+func (y *YQuery) moveNode(from []string, to []string, delimiter string) error {
+	fromPath := strings.Join(from, delimiter)
+	toPath := strings.Join(to, delimiter)
+	if len(to) == 0 || len(to) > len(from) && strings.Join(to[:len(from)], delimiter) == fromPath {
+		return fmt.Errorf("cannot move item '%s' into itself", fromPath)
+	}
+	parent, index, err := y.locate(from, delimiter, false)
+	if err != nil {
+		return err
+	}
+	node := parent.Content[index]
+	content := parent.Content
+	key := removeContent(parent, index)
+	container, err := y.locateNode(to[:len(to)-1], delimiter)
+	if err == nil {
+		err = insertContent(container, to[len(to)-1], node, key)
+	}
+	if err != nil {
+		// keep the node unchanged when failed
+		parent.Content = content
+		return fmt.Errorf("cannot move item '%s' to '%s': %s", fromPath, toPath, err)
+	}
+	orderAnchors(y.RootNode)
+	return nil
+}
@@ -0,0 +1,98 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+func TestDelete(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	asserts.NoError(yq.Delete("a"))
+	asserts.NoError(yq.Delete("n[0]"))
+	asserts.NoError(yq.Delete("j.i"))
+	asserts.NoError(yq.Delete("f"))
+	asserts.NoError(yq.Delete("c"))
+	_, err := yq.Get("a")
+	asserts.Error(err)
+	res, _ := yq.Get("n[0]")
+	asserts.Equal("2", res)
+	// item defined in merge appears after deleting override one
+	res, _ = yq.Get("j.i")
+	asserts.Equal("other item", res)
+	_, err = yq.Get("c")
+	asserts.Error(err)
+}
+
+func TestDeleteShouldError(t *testing.T) {
+	asserts := assert.New(t)
+	testCases := []string{
+		"notExist",
+		"n[999]",
+		"f.d",
+		"j.h",
+		"c",
+		"",
+	}
+	for _, c := range testCases {
+		yq, _ := yquery.Unmarshal([]byte(data))
+		asserts.Error(yq.Delete(c), c)
+	}
+}
+
+func TestMove(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	asserts.NoError(yq.Move("a", "c.a"))
+	asserts.NoError(yq.Move("n[0]", "n[2]"))
+	asserts.NoError(yq.Move("b", "n[0]"))
+	testCases := []casePair{
+		{"c.a", "title"},
+		{"f.a", "title"},
+		{"n[0]", "112"},
+		{"n[1]", "2"},
+		{"n[3]", "1"},
+	}
+	for _, c := range testCases {
+		res, err := yq.Get(c.Parser)
+		asserts.NoError(err, c.Parser)
+		asserts.Equal(c.Value, res, c.Parser)
+	}
+	_, err := yq.Get("a")
+	asserts.Error(err)
+}
+
+func TestMoveShouldError(t *testing.T) {
+	asserts := assert.New(t)
+	testCases := []casePair{
+		{"notExist", "a"},
+		{"a", "notExist.a"},
+		{"a", "b.a"},
+		{"a", "n[9]"},
+		{"c", "c.d.e"},
+		{"f.d", "a"},
+	}
+	for _, c := range testCases {
+		yq, _ := yquery.Unmarshal([]byte(data))
+		origin, _ := yq.Marshal()
+		asserts.Error(yq.Move(c.Parser, c.Value), c.Parser)
+		out, _ := yq.Marshal()
+		asserts.Equal(string(origin), string(out))
+	}
+}
+
+func TestMoveAnchorAfterReference(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte("a: &a\n  k: 1\nb: *a\nc:\n  <<: *a\n"))
+	asserts.NoError(yq.Move("a", "d"))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("b: &a\n    k: 1\nc:\n    <<: *a\nd: *a\n", string(out))
+	_, err = yquery.Unmarshal(out)
+	asserts.NoError(err)
+	res, _ := yq.Get("d.k")
+	asserts.Equal("1", res)
+}
@@ -0,0 +1,278 @@
+package yquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeType is the type of a change between two documents
+type ChangeType int
+
+const (
+	// Added means the item only exists in the new document
+	Added ChangeType = iota
+	// Removed means the item only exists in the old document
+	Removed
+	// Changed means the value of the item is different
+	Changed
+)
+
+// Change is a difference between two documents
+type Change struct {
+	Type ChangeType
+	// Path is the parser string of the item, which could be used in Get
+	Path string
+	// Old is the value in the old document, nil if it is added
+	Old *yaml.Node
+	// New is the value in the new document, nil if it is removed
+	New *yaml.Node
+
+	// pointer is the reference tokens of JSON Pointer
+	pointer []string
+}
+
+// Changes is a list of Change, in the order of applying them
+type Changes []Change
+
+// DiffConfig is the optional parameter for Diff
+type DiffConfig struct {
+	// Delimiter is the custom delimiter used in Path of changes
+	Delimiter string
+	// Raw could be set to true to compare documents without resolving anchor reference and merge key
+	// By default, documents are compared after Explode, so that only the data matters.
+	Raw bool
+}
+
+// Diff compare document a (old) and b (new), return changes by path
+// Mapping items are compared by key, sequence items are compared by index,
+// and scalars are compared by their data, e.g. `1` and `0x1` are the same, but `1` and `"1"` are different.
+// Comments and styles are ignored.
+func Diff(a, b *YQuery, config ...DiffConfig) (Changes, error) {
+	if len(config) > 1 {
+		return nil, fmt.Errorf("diff could only get 0 or 1 config, got %d", len(config))
+	}
+	c := DiffConfig{}
+	if len(config) == 1 {
+		c = config[0]
+	}
+	delimiter, err := getDelimiter([]Config{{Delimiter: c.Delimiter}})
+	if err != nil {
+		return nil, err
+	}
+	nodeA, nodeB := a.RootNode, b.RootNode
+	if !c.Raw {
+		if nodeA, err = explodeNode(nodeA, nil); err != nil {
+			return nil, err
+		}
+		if nodeB, err = explodeNode(nodeB, nil); err != nil {
+			return nil, err
+		}
+	}
+	d := differ{delimiter: delimiter}
+	switch {
+	case nodeA == nil && nodeB == nil:
+	case nodeA == nil:
+		d.add(Added, nil, nil, nil, nodeB)
+	case nodeB == nil:
+		d.add(Removed, nil, nil, nodeA, nil)
+	default:
+		d.diff(nil, nil, nodeA, nodeB)
+	}
+	return d.changes, nil
+}
+
+type differ struct {
+	delimiter string
+	changes   Changes
+}
+
+func (d *differ) add(changeType ChangeType, slices []string, pointer []string, old *yaml.Node, new *yaml.Node) {
+	path := strings.Replace(strings.Join(slices, d.delimiter), d.delimiter+"[", "[", -1)
+	d.changes = append(d.changes, Change{
+		Type:    changeType,
+		Path:    path,
+		Old:     old,
+		New:     new,
+		pointer: append([]string{}, pointer...),
+	})
+}
+
+func (d *differ) diff(slices []string, pointer []string, a *yaml.Node, b *yaml.Node) {
+	if a.Kind != b.Kind || a.Kind == yaml.ScalarNode || a.Kind == yaml.AliasNode {
+		if !sameNode(a, b) {
+			d.add(Changed, slices, pointer, a, b)
+		}
+		return
+	}
+	if a.Kind == yaml.SequenceNode {
+		for i := 0; i < len(a.Content) && i < len(b.Content); i++ {
+			d.diff(append(slices, fmt.Sprintf("[%d]", i)), append(pointer, fmt.Sprint(i)), a.Content[i], b.Content[i])
+		}
+		// remove from the last one, so that index is still correct when applying
+		for i := len(a.Content) - 1; i >= len(b.Content); i-- {
+			d.add(Removed, append(slices, fmt.Sprintf("[%d]", i)), append(pointer, fmt.Sprint(i)), a.Content[i], nil)
+		}
+		for i := len(a.Content); i < len(b.Content); i++ {
+			d.add(Added, append(slices, fmt.Sprintf("[%d]", i)), append(pointer, fmt.Sprint(i)), nil, b.Content[i])
+		}
+		return
+	}
+	for i := 0; i+1 < len(a.Content); i += 2 {
+		key := a.Content[i].Value
+		keySlices, keyPointer := append(slices, key), append(pointer, key)
+		if value := mappingValue(b, key); value != nil {
+			d.diff(keySlices, keyPointer, a.Content[i+1], value)
+		} else {
+			d.add(Removed, keySlices, keyPointer, a.Content[i+1], nil)
+		}
+	}
+	for i := 0; i+1 < len(b.Content); i += 2 {
+		key := b.Content[i].Value
+		if mappingValue(a, key) == nil {
+			d.add(Added, append(slices, key), append(pointer, key), nil, b.Content[i+1])
+		}
+	}
+}
+
+// mappingValue return value of key in mapping, merge items are not searched
+// It returns nil if node is not a mapping.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sameNode compare scalar or alias node by its data
+func sameNode(a *yaml.Node, b *yaml.Node) bool {
+	if a.Kind != b.Kind {
+		return false
+	}
+	if a.Kind == yaml.AliasNode {
+		return a.Value == b.Value
+	}
+	var dataA, dataB interface{}
+	if a.Decode(&dataA) != nil || b.Decode(&dataB) != nil {
+		return a.ShortTag() == b.ShortTag() && a.Value == b.Value
+	}
+	return reflect.DeepEqual(dataA, dataB)
+}
+
+// JSONPatch export changes as RFC 6902 JSON Patch
+// Applying it with ApplyJSONPatch to the old document results the new document, changes of value type
+// and order of keys included. Values are written as MarshalJSON does, it returns error for values having no JSON equivalent.
+// Unless Raw is used in Diff, the old document should be exploded first, since anchor reference cannot be modified.
+func (c Changes) JSONPatch() ([]byte, error) {
+	operations := make([]jsonPatchOperation, 0, len(c))
+	for _, change := range c {
+		tokens := make([]string, len(change.pointer))
+		for i, token := range change.pointer {
+			tokens[i] = strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+		}
+		operation := jsonPatchOperation{Path: "/" + strings.Join(tokens, "/")}
+		if len(tokens) == 0 {
+			operation.Path = ""
+		}
+		switch change.Type {
+		case Added:
+			operation.Op = "add"
+		case Removed:
+			operation.Op = "remove"
+		case Changed:
+			operation.Op = "replace"
+		}
+		if change.New != nil {
+			node, err := explodeNode(change.New, nil)
+			if err != nil {
+				return nil, err
+			}
+			var buffer bytes.Buffer
+			if err := writeJSON(&buffer, change.Path, node); err != nil {
+				return nil, fmt.Errorf("cannot convert value of '%s' to json: %s", change.Path, err)
+			}
+			operation.Value = buffer.Bytes()
+		}
+		operations = append(operations, operation)
+	}
+	return json.Marshal(operations)
+}
+
+// MarshalJSON omit "from" and "value" when they are empty
+func (o jsonPatchOperation) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{
+		"op":   o.Op,
+		"path": o.Path,
+	}
+	if o.From != "" {
+		m["from"] = o.From
+	}
+	if o.Value != nil {
+		m["value"] = o.Value
+	}
+	return json.Marshal(m)
+}
+
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorReset  = "\x1b[0m"
+)
+
+// Report return a human readable report of changes, one change per item
+//     + added.item: new value
+//     - removed.item: old value
+//     ~ changed.item: old value -> new value
+//
+// Lines are colored with ANSI escape code when color is true.
+func (c Changes) Report(color bool) string {
+	var builder strings.Builder
+	for _, change := range c {
+		var line, lineColor string
+		switch change.Type {
+		case Added:
+			line = fmt.Sprintf("+ %s: %s", change.Path, reportValue(change.New))
+			lineColor = colorGreen
+		case Removed:
+			line = fmt.Sprintf("- %s: %s", change.Path, reportValue(change.Old))
+			lineColor = colorRed
+		case Changed:
+			line = fmt.Sprintf("~ %s: %s -> %s", change.Path, reportValue(change.Old), reportValue(change.New))
+			lineColor = colorYellow
+		}
+		if color {
+			line = lineColor + line + colorReset
+		}
+		builder.WriteString(line + "\n")
+	}
+	return builder.String()
+}
+
+// reportValue return value of node in one line
+func reportValue(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return fmt.Sprintf("%q", node.Value)
+	}
+	n := cloneNode(node)
+	walkNode(n, func(n *yaml.Node) {
+		n.HeadComment, n.LineComment, n.FootComment = "", "", ""
+		if n.Kind == yaml.MappingNode || n.Kind == yaml.SequenceNode {
+			n.Style |= yaml.FlowStyle
+		}
+	})
+	out, err := yaml.Marshal(untagMergeKeys(n))
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return strings.TrimSpace(string(out))
+}
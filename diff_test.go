@@ -0,0 +1,126 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var diffOld = `
+a: 1
+b: &b
+  c: string c
+  d: [1, 2, 3]
+e: *b
+f: removed
+g:
+  h: 0x10
+`
+
+// language=yaml
+var diffNew = `
+# comments and styles are ignored
+a: 2
+b:
+  c: "string c"
+  d:
+    - 1
+    - 5
+e:
+  c: string c
+  d: [1, 5]
+g:
+  h: 16
+  i: {j: added}
+`
+
+func TestDiff(t *testing.T) {
+	asserts := assert.New(t)
+	a, _ := yquery.Unmarshal([]byte(diffOld))
+	b, _ := yquery.Unmarshal([]byte(diffNew))
+	changes, err := yquery.Diff(a, b)
+	asserts.NoError(err)
+	report := changes.Report(false)
+	asserts.Equal(`~ a: "1" -> "2"
+~ b.d[1]: "2" -> "5"
+- b.d[2]: "3"
+~ e.d[1]: "2" -> "5"
+- e.d[2]: "3"
+- f: "removed"
++ g.i: {j: added}
+`, report)
+	asserts.Contains(changes.Report(true), "\x1b[32m+ g.i: {j: added}\x1b[0m\n")
+	asserts.Equal(yquery.Removed, changes[5].Type)
+	asserts.Equal("removed", changes[5].Old.Value)
+
+	patch, err := changes.JSONPatch()
+	asserts.NoError(err)
+	asserts.NoError(a.Explode())
+	asserts.NoError(a.ApplyJSONPatch(patch))
+	changes, err = yquery.Diff(a, b)
+	asserts.NoError(err)
+	asserts.Empty(changes)
+}
+
+func TestDiffRaw(t *testing.T) {
+	asserts := assert.New(t)
+	a, _ := yquery.Unmarshal([]byte(diffOld))
+	b, _ := yquery.Unmarshal([]byte(diffOld))
+	asserts.NoError(b.Explode())
+	changes, err := yquery.Diff(a, b)
+	asserts.NoError(err)
+	asserts.Empty(changes)
+	changes, err = yquery.Diff(a, b, yquery.DiffConfig{Raw: true, Delimiter: "/"})
+	asserts.NoError(err)
+	asserts.Equal("~ e: *b -> {c: string c, d: [1, 2, 3]}\n", changes.Report(false))
+}
+
+func TestDiffJSONPatchTypeChange(t *testing.T) {
+	asserts := assert.New(t)
+	a, _ := yquery.Unmarshal([]byte("a: \"1\"\nb: 2\nc: !!str true\nd: 'x'\ne: [1, \"2\"]\nf: null\n"))
+	b, _ := yquery.Unmarshal([]byte("a: 1\nb: \"2\"\nc: true\nd: 3.5\ne: [\"1\", 2]\nf: \"null\"\n"))
+	changes, err := yquery.Diff(a, b)
+	asserts.NoError(err)
+	asserts.Len(changes, 7)
+	patch, err := changes.JSONPatch()
+	asserts.NoError(err)
+	asserts.NoError(a.ApplyJSONPatch(patch))
+	changes, err = yquery.Diff(a, b)
+	asserts.NoError(err)
+	asserts.Empty(changes, changes.Report(false))
+}
+
+func TestDiffJSONPatchKeyOrder(t *testing.T) {
+	asserts := assert.New(t)
+	a, _ := yquery.Unmarshal([]byte("x: 1\nw: old\n"))
+	// language=yaml
+	b, _ := yquery.Unmarshal([]byte(`x: 1
+w:
+    z: 1
+    a:
+        - 2
+        - y: 3
+          b: 4
+v:
+    z: 1
+    a: 2
+`))
+	changes, err := yquery.Diff(a, b)
+	asserts.NoError(err)
+	patch, err := changes.JSONPatch()
+	asserts.NoError(err)
+	asserts.Equal(`[{"op":"replace","path":"/w","value":{"z":1,"a":[2,{"y":3,"b":4}]}},{"op":"add","path":"/v","value":{"z":1,"a":2}}]`, string(patch))
+	asserts.NoError(a.ApplyJSONPatch(patch))
+	out, _ := a.Marshal()
+	expected, _ := b.Marshal()
+	asserts.Equal(string(expected), string(out))
+
+	a, _ = yquery.Unmarshal([]byte("x: 1\nw: old\n"))
+	b, _ = yquery.Unmarshal([]byte("x: 1\nw: {1: a}\n"))
+	changes, _ = yquery.Diff(a, b)
+	_, err = changes.JSONPatch()
+	asserts.Error(err)
+}
@@ -0,0 +1,176 @@
+package yquery
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GetDocumentComment return comments of the document
+// Head is the comment at the beginning of the document separated from the first item by an empty line,
+// Foot is the comment at the end of the document separated from the last item by an empty line.
+// Other fields are always empty.
+func (y *YQuery) GetDocumentComment() Comment {
+	return Comment{
+		Head: parseComment(y.document.HeadComment),
+		Foot: parseComment(y.document.FootComment),
+	}
+}
+
+// SetDocumentComment set comment of the document, comment is removed if text is empty
+// Only HeadComment and FootComment are available for the document.
+func (y *YQuery) SetDocumentComment(kind CommentKind, text string) error {
+	return y.modify(Operation{Name: "SetDocumentComment"}, func() error {
+		switch kind {
+		case HeadComment:
+			y.document.HeadComment = formatComment(text)
+		case FootComment:
+			y.document.FootComment = formatComment(text)
+		default:
+			return fmt.Errorf("the document only has head and foot comment, got comment kind %d", kind)
+		}
+		y.document.Kind = yaml.DocumentNode
+		return nil
+	})
+}
+
+// documentNode return the document node holding RootNode, or RootNode itself if there is no document node
+func (y *YQuery) documentNode() *yaml.Node {
+	if y.document.Kind != yaml.DocumentNode {
+		return y.RootNode
+	}
+	document := y.document
+	document.Content = []*yaml.Node{y.RootNode}
+	return &document
+}
+
+// encode write the document with its directives and document start in format
+// Only comments of the document are written for an empty document.
+func (y *YQuery) encode(f format) ([]byte, error) {
+	if y.RootNode == nil {
+		comments := []string{y.document.HeadComment, y.document.FootComment}
+		text := strings.TrimSpace(strings.Join(comments, "\n\n"))
+		if text != "" {
+			text += "\n"
+		}
+		return []byte(text), nil
+	}
+	f.directives = y.directives
+	f.documentStart = f.documentStart || y.documentStart
+	return encode(y.documentNode(), f)
+}
+
+// commentLines return comment lines in data
+func commentLines(data []byte) string {
+	var comments []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "#") {
+			comments = append(comments, line)
+		}
+	}
+	return strings.Join(comments, "\n")
+}
+
+// documentHeader is what comes before the content of the first document
+type documentHeader struct {
+	// directives are lines before the document start "---", e.g. "%YAML 1.1"
+	directives []string
+	// start is set if there is the document start "---"
+	start bool
+	// comment is the comment after the document start, separated from the first item by an empty line
+	comment string
+}
+
+// unmarshalDocument parse the first document of in, return its header and the document node
+func unmarshalDocument(in []byte) (documentHeader, yaml.Node, error) {
+	var document yaml.Node
+	header, data := splitHeader(in)
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return header, document, err
+	}
+	if document.HeadComment == "" {
+		document.HeadComment = header.comment
+	}
+	return header, document, nil
+}
+
+// splitHeader return the header of the first document and data to parse
+// "%YAML" directives are replaced by empty lines in data, since go-yaml only accepts version 1.1,
+// "%TAG" directives are kept for go-yaml to resolve tags.
+// The comment after "---" is replaced by empty lines as well, go-yaml reads it as a comment of the first item,
+// or even its foot comment if there are directives.
+func splitHeader(in []byte) (documentHeader, []byte) {
+	lines := bytes.Split(in, []byte("\n"))
+	var header documentHeader
+	for i, line := range lines {
+		text := strings.TrimSpace(string(line))
+		switch {
+		case text == "---" || strings.HasPrefix(text, "--- "):
+			header.start = true
+			data := make([][]byte, len(lines))
+			copy(data, lines)
+			for j := 0; j < i; j++ {
+				if bytes.HasPrefix(lines[j], []byte("%YAML")) {
+					data[j] = nil
+				}
+			}
+			if text == "---" {
+				header.comment = startComment(lines[i+1:], data[i+1:])
+			}
+			return header, bytes.Join(data, []byte("\n"))
+		case strings.HasPrefix(text, "%"):
+			header.directives = append(header.directives, text)
+		case text != "" && !strings.HasPrefix(text, "#"):
+			return documentHeader{}, in
+		}
+	}
+	return documentHeader{}, in
+}
+
+// startComment return the comment at the beginning of lines which is followed by an empty line and then the first item
+// Lines of the comment are removed from data.
+func startComment(lines [][]byte, data [][]byte) string {
+	// end is the last empty line before the first item
+	end, found := -1, false
+	for i, line := range lines {
+		text := strings.TrimSpace(string(line))
+		if text == "" {
+			end = i
+			continue
+		}
+		if !strings.HasPrefix(text, "#") {
+			found = !strings.HasPrefix(text, "---") && !strings.HasPrefix(text, "...")
+			break
+		}
+	}
+	if !found {
+		return ""
+	}
+	var comments []string
+	for i := 0; i < end; i++ {
+		text := strings.TrimSpace(string(lines[i]))
+		if text == "" && (len(comments) == 0 || comments[len(comments)-1] == "") {
+			continue
+		}
+		comments = append(comments, text)
+		data[i] = nil
+	}
+	return strings.TrimSpace(strings.Join(comments, "\n"))
+}
+
+// shortenTags write verbatim tags in out with handles declared by "%TAG" directives
+// go-yaml writes tags resolved from handles as verbatim tags, e.g. "!<tag:example.com,2000:x>" for "!e!x".
+func shortenTags(out []byte, directives []string) []byte {
+	for _, directive := range directives {
+		fields := strings.Fields(directive)
+		if len(fields) != 3 || fields[0] != "%TAG" {
+			continue
+		}
+		verbatim := regexp.MustCompile(`!<` + regexp.QuoteMeta(fields[2]) + `([^>\s]*)>`)
+		out = verbatim.ReplaceAll(out, []byte(fields[1]+"$1"))
+	}
+	return out
+}
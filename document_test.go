@@ -0,0 +1,94 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var documentData = `# head of document
+
+# head of a
+a: 1
+
+# foot of document
+`
+
+func TestDocumentComment(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(documentData))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal(documentData, string(out))
+	asserts.Equal(yquery.Comment{Head: "head of document", Foot: "foot of document"}, yq.GetDocumentComment())
+
+	yq.SetHistoryDepth(1)
+	asserts.NoError(yq.SetDocumentComment(yquery.HeadComment, "new head\nsecond line"))
+	asserts.NoError(yq.SetDocumentComment(yquery.FootComment, ""))
+	asserts.Error(yq.SetDocumentComment(yquery.LineComment, "line"))
+	out, _ = yq.Marshal()
+	asserts.Equal("# new head\n# second line\n\n# head of a\na: 1\n", string(out))
+	out, _ = yq.MarshalMinimal()
+	asserts.Equal("# new head\n# second line\n\n# head of a\na: 1\n", string(out))
+
+	_, err = yq.Undo()
+	asserts.NoError(err)
+	asserts.Equal(yquery.Comment{Head: "new head\nsecond line", Foot: "foot of document"}, yq.GetDocumentComment())
+
+	yq, _ = yquery.Unmarshal([]byte("a: 1\n"))
+	asserts.NoError(yq.SetDocumentComment(yquery.HeadComment, "head"))
+	out, _ = yq.Marshal()
+	asserts.Equal("# head\n\na: 1\n", string(out))
+}
+
+func TestDirectives(t *testing.T) {
+	asserts := assert.New(t)
+	data := "%YAML 1.2\n%TAG !e! tag:example.com,2000:\n---\na: !e!x 1\nb: 2\n"
+	yq, err := yquery.Unmarshal([]byte(data))
+	asserts.NoError(err)
+	node, _ := yq.GetNode("a", true)
+	asserts.Equal("tag:example.com,2000:x", node.Tag)
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal(data, string(out))
+
+	asserts.NoError(yq.Set("b", "3"))
+	out, err = yq.MarshalMinimal()
+	asserts.NoError(err)
+	asserts.Equal("%YAML 1.2\n%TAG !e! tag:example.com,2000:\n---\na: !e!x 1\nb: 3\n", string(out))
+
+	yq, _ = yquery.Unmarshal([]byte("a: 1\n---\nb: 2\n"))
+	out, _ = yq.Marshal()
+	asserts.Equal("a: 1\n", string(out))
+
+	// comment after the document start is the document comment if it is separated from the first item
+	tests := []string{
+		"%YAML 1.1\n---\n# head\n\na: 1\n",
+		"%TAG !e! tag:example.com,2000:\n---\n# head\n\n# second\n\n# head of a\na: !e!x 1\n",
+		"---\n# head\n\na: 1\n",
+		"---\n# head of a\na: 1\n",
+	}
+	for _, data := range tests {
+		yq, err = yquery.Unmarshal([]byte(data))
+		asserts.NoError(err)
+		out, err = yq.Marshal()
+		asserts.NoError(err)
+		asserts.Equal(data, string(out))
+		asserts.NoError(yq.Set("b", "2"))
+		out, err = yq.MarshalMinimal()
+		asserts.NoError(err)
+		asserts.Equal(data+"b: 2\n", string(out))
+	}
+	yq, _ = yquery.Unmarshal([]byte(tests[1]))
+	asserts.Equal(yquery.Comment{Head: "head\n\nsecond"}, yq.GetDocumentComment())
+	v, _ := yq.GetComment("a")
+	asserts.Equal("head of a", v.KeyHead)
+	yq, _ = yquery.Unmarshal([]byte(tests[2]))
+	asserts.Equal(yquery.Comment{Head: "head"}, yq.GetDocumentComment())
+	asserts.NoError(yq.SetDocumentComment(yquery.HeadComment, "new head"))
+	out, _ = yq.MarshalMinimal()
+	asserts.Equal("---\n# new head\n\na: 1\n", string(out))
+}
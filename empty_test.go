@@ -0,0 +1,113 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+func TestEmptyDocument(t *testing.T) {
+	asserts := assert.New(t)
+	for _, data := range []string{"", "\n", "# only comment\n\n# second\n"} {
+		yq, err := yquery.Unmarshal([]byte(data))
+		asserts.NoError(err)
+		asserts.Nil(yq.RootNode)
+		_, err = yq.Get("a")
+		asserts.Error(err)
+		asserts.NoError(yq.Explode())
+		asserts.NoError(yq.ExtractAnchors())
+		out, err := yq.Marshal()
+		asserts.NoError(err)
+		asserts.Equal(data == "# only comment\n\n# second\n", len(out) > 0)
+	}
+
+	yq, _ := yquery.Unmarshal([]byte("# only comment\n"))
+	asserts.NoError(yq.Set("a.b", "1", yquery.Config{Recursive: true}))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("# only comment\n\na:\n    b: 1\n", string(out))
+}
+
+func TestEmptyDocumentDiff(t *testing.T) {
+	asserts := assert.New(t)
+	empty, _ := yquery.Unmarshal(nil)
+	yq, _ := yquery.Unmarshal([]byte("a: 1\n"))
+	changes, err := yquery.Diff(empty, empty)
+	asserts.NoError(err)
+	asserts.Len(changes, 0)
+	changes, err = yquery.Diff(empty, yq)
+	asserts.NoError(err)
+	asserts.Len(changes, 1)
+	asserts.Equal(yquery.Added, changes[0].Type)
+
+	asserts.NoError(empty.StrategicMerge(yq))
+	res, err := empty.Get("a")
+	asserts.NoError(err)
+	asserts.Equal("1", res)
+}
+
+func TestScalarRoot(t *testing.T) {
+	asserts := assert.New(t)
+	yq, err := yquery.Unmarshal([]byte("hello\n"))
+	asserts.NoError(err)
+	_, err = yq.Get("a")
+	asserts.Error(err)
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("hello\n", string(out))
+
+	// the root could not be addressed, it is read through RootNode
+	_, err = yq.Get("")
+	asserts.Error(err)
+	asserts.Error(yq.Delete(""))
+	asserts.Equal("hello", yq.RootNode.Value)
+
+	// the scalar root is not replaced by a mapping or sequence silently
+	asserts.Error(yq.Set("a", "1"))
+	asserts.Error(yq.Set("[0]", "1"))
+	asserts.Error(yq.Set("a.b", "1", yquery.Config{Recursive: true}))
+	out, err = yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("hello\n", string(out))
+	yq.RootNode = nil
+	asserts.NoError(yq.Set("a", "1"))
+	out, err = yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("a: 1\n", string(out))
+}
+
+func TestSequenceRoot(t *testing.T) {
+	asserts := assert.New(t)
+	yq, err := yquery.Unmarshal([]byte("- name: a\n- name: b\n"))
+	asserts.NoError(err)
+	res, err := yq.Get("[1].name")
+	asserts.NoError(err)
+	asserts.Equal("b", res)
+	asserts.NoError(yq.Set("[0].name", "c"))
+	asserts.NoError(yq.Set("[2]", "d"))
+	asserts.NoError(yq.Delete("[1]"))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("- name: c\n- d\n", string(out))
+
+	empty, _ := yquery.Unmarshal(nil)
+	asserts.NoError(empty.Set("[0]", "a"))
+	out, err = empty.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("- a\n", string(out))
+}
+
+func TestNew(t *testing.T) {
+	asserts := assert.New(t)
+	yq := yquery.New()
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("{}\n", string(out))
+	asserts.NoError(yq.Set("a", "1"))
+	asserts.NoError(yq.Set("b.c", "2", yquery.Config{Recursive: true}))
+	out, err = yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal("a: 1\nb:\n    c: 2\n", string(out))
+}
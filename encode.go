@@ -0,0 +1,398 @@
+package yquery
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultIndent is the indentation width of yaml.Marshal
+const defaultIndent = 4
+
+// MarshalOptions is the parameter for MarshalWith
+type MarshalOptions struct {
+	// Indent is the number of spaces of each indentation level, default is 4
+	Indent int
+	// CompactSequence could be set to true to write sequences in mapping without indentation
+	//     key:
+	//     - item
+	CompactSequence bool
+	// LineWidth is the preferred width of lines, long plain, quoted and folded strings are folded at spaces
+	// Keys, strings in flow collections and words longer than the width are not folded. 0 means no limit.
+	LineWidth int
+	// QuoteStyle is SingleQuotedStyle or DoubleQuotedStyle to write quoted strings with it,
+	// strings which need quote are quoted with it too. Default KeepStyle writes strings as they are.
+	QuoteStyle ValueStyle
+	// DocumentStart could be set to true to write "---" at the beginning of the document
+	DocumentStart bool
+}
+
+// MarshalWith marshal the document with options
+//     out, err := yq.MarshalWith(yquery.MarshalOptions{Indent: 2, CompactSequence: true})
+func (y *YQuery) MarshalWith(options MarshalOptions) ([]byte, error) {
+	if options.Indent < 0 {
+		return nil, fmt.Errorf("indent could not be negative, got %d", options.Indent)
+	}
+	if options.LineWidth < 0 {
+		return nil, fmt.Errorf("line width could not be negative, got %d", options.LineWidth)
+	}
+	f := format{
+		indent:          options.Indent,
+		compactSequence: options.CompactSequence,
+		lineWidth:       options.LineWidth,
+		quoteStyle:      options.QuoteStyle,
+		documentStart:   options.DocumentStart,
+	}
+	if f.indent == 0 {
+		f.indent = defaultIndent
+	}
+	return y.encode(f)
+}
+
+// format is the way to write a document
+type format struct {
+	// indent is the number of spaces of each indentation level
+	indent int
+	// compactSequence writes sequences in mapping at the same indentation as the key
+	//     key:
+	//     - item
+	compactSequence bool
+	// lineWidth is the preferred width of lines, long strings are folded, 0 means no limit
+	lineWidth int
+	// crlf uses "\r\n" as line ending
+	crlf bool
+	// noTrailingNewline removes the newline at the end of the document
+	noTrailingNewline bool
+	// quoteStyle is the quote used for quoted strings, KeepStyle writes them as they are
+	quoteStyle ValueStyle
+	// documentStart writes "---" at the beginning of the document
+	documentStart bool
+	// directives are written before the document start, e.g. "%YAML 1.1"
+	directives []string
+}
+
+// defaultFormat is the format of yaml.Marshal
+var defaultFormat = format{indent: defaultIndent}
+
+// detectFormat find the format of data, node is the document parsed from data
+// Items not found in data use the default format.
+func detectFormat(data []byte, node *yaml.Node) format {
+	f := defaultFormat
+	f.crlf = bytes.Contains(data, []byte("\r\n"))
+	f.noTrailingNewline = len(data) > 0 && data[len(data)-1] != '\n'
+	f.documentStart = bytes.HasPrefix(data, []byte("---"))
+	indentFound, sequenceFound := false, false
+	walkNode(node, func(n *yaml.Node) {
+		if n.Kind != yaml.MappingNode || n.Style&yaml.FlowStyle != 0 {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if len(value.Content) == 0 || value.Style&yaml.FlowStyle != 0 || value.Line == key.Line {
+				continue
+			}
+			switch {
+			case value.Kind == yaml.MappingNode && !indentFound:
+				f.indent, indentFound = value.Column-key.Column, true
+			case value.Kind == yaml.SequenceNode && !sequenceFound:
+				f.compactSequence, sequenceFound = value.Column == key.Column, true
+				if !indentFound && !f.compactSequence {
+					f.indent = value.Column - key.Column
+				}
+			}
+		}
+	})
+	return f
+}
+
+// encode write node in format
+func encode(node *yaml.Node, f format) ([]byte, error) {
+	if f.quoteStyle != KeepStyle {
+		node = cloneNode(node)
+		if err := quoteStrings(node, f.quoteStyle); err != nil {
+			return nil, err
+		}
+	}
+	node = untagMergeKeys(node)
+	var buffer bytes.Buffer
+	for _, directive := range f.directives {
+		buffer.WriteString(directive + "\n")
+	}
+	if f.documentStart || len(f.directives) > 0 {
+		buffer.WriteString("---\n")
+	}
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(f.indent)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	out := shortenTags(buffer.Bytes(), f.directives)
+	if f.compactSequence {
+		var err error
+		if out, err = compactSequences(out); err != nil {
+			return nil, err
+		}
+	}
+	if f.lineWidth > 0 {
+		var err error
+		if out, err = foldLines(out, f.lineWidth); err != nil {
+			return nil, err
+		}
+	}
+	if f.noTrailingNewline {
+		out = bytes.TrimSuffix(out, []byte("\n"))
+	}
+	if f.crlf {
+		out = bytes.Replace(out, []byte("\n"), []byte("\r\n"), -1)
+	}
+	return out, nil
+}
+
+// untagMergeKeys return node with merge keys written as "<<" instead of "!!merge <<"
+// go-yaml v3 writes the tag of parsed merge keys, node is copied if it has any of them.
+// Merge keys tagged explicitly in the source keep their tags.
+func untagMergeKeys(node *yaml.Node) *yaml.Node {
+	isMergeKey := func(n *yaml.Node) bool {
+		return n.Kind == yaml.ScalarNode && n.Tag == mergeTag && n.Style&yaml.TaggedStyle == 0
+	}
+	found := false
+	walkNode(node, func(n *yaml.Node) {
+		found = found || isMergeKey(n)
+	})
+	if !found {
+		return node
+	}
+	node = cloneNode(node)
+	walkNode(node, func(n *yaml.Node) {
+		if isMergeKey(n) {
+			n.Tag = ""
+		}
+	})
+	return node
+}
+
+// quoteStrings write quoted strings in node with style, and strings need quote as well
+func quoteStrings(node *yaml.Node, style ValueStyle) error {
+	if style != SingleQuotedStyle && style != DoubleQuotedStyle {
+		return fmt.Errorf("quote style could only be SingleQuotedStyle or DoubleQuotedStyle")
+	}
+	var err error
+	walkNode(node, func(n *yaml.Node) {
+		if err != nil || n.Kind != yaml.ScalarNode || n.ShortTag() != strTag || n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			return
+		}
+		quoted := n.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0
+		if !quoted {
+			var out []byte
+			if out, err = yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Tag: strTag, Value: n.Value}); err != nil {
+				return
+			}
+			quoted = out[0] == '\'' || out[0] == '"'
+		}
+		if quoted {
+			n.Style = n.Style&yaml.TaggedStyle | yamlStyles[style]
+		}
+	})
+	return err
+}
+
+// compactSequences move block sequences in mapping to the indentation of their keys
+// go-yaml always indents them, the positions of nodes parsed from out are used to find lines of these sequences.
+func compactSequences(out []byte) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(out, &document); err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(out), "\n")
+	dedent := make([]int, len(lines))
+	// dedentRange dedent lines from start to end (1-based), which are at least indented to column
+	dedentRange := func(start, end, column, width int) {
+		for line := start; line <= end && line <= len(lines); line++ {
+			text := lines[line-1]
+			if len(text)-len(strings.TrimLeft(text, " ")) >= column-1 {
+				dedent[line-1] += width
+			}
+		}
+	}
+	// visit find sequences in node, end is the last line of node
+	var visit func(node *yaml.Node, end int)
+	visit = func(node *yaml.Node, end int) {
+		for i, content := range node.Content {
+			contentEnd := end
+			next := i + 1
+			if node.Kind == yaml.MappingNode {
+				next = i + 2
+				if i%2 == 0 {
+					continue
+				}
+			}
+			if next < len(node.Content) {
+				contentEnd = node.Content[next].Line - 1
+			}
+			if node.Kind == yaml.MappingNode {
+				key := node.Content[i-1]
+				if content.Kind == yaml.SequenceNode && content.Style&yaml.FlowStyle == 0 && content.Column > key.Column {
+					dedentRange(content.Line, contentEnd, content.Column, content.Column-key.Column)
+				}
+			}
+			visit(content, contentEnd)
+		}
+	}
+	visit(&document, len(lines))
+	for i, width := range dedent {
+		lines[i] = lines[i][width:]
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// foldLines fold long plain, quoted and folded strings in out at spaces, so that lines are not longer than width
+// go-yaml v3 never folds, the positions of nodes parsed from out are used to find lines of these strings.
+func foldLines(out []byte, width int) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(out, &document); err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(out), "\n")
+	// folded are lines replacing the line (0-based)
+	folded := map[int][]string{}
+	var visit func(node *yaml.Node, flow bool)
+	visit = func(node *yaml.Node, flow bool) {
+		flow = flow || node.Style&yaml.FlowStyle != 0
+		for i, content := range node.Content {
+			switch {
+			case content.Kind != yaml.ScalarNode:
+				visit(content, flow)
+			case flow || node.Kind == yaml.MappingNode && i%2 == 0 || content.Column == 1:
+				// keys could not be in multiple lines, and continuation lines at column 1 could be "---"
+			case content.Style&yaml.FoldedStyle != 0:
+				foldBlock(lines, content, width, folded)
+			case content.Style&yaml.LiteralStyle == 0:
+				foldScalar(lines, content, width, folded)
+			}
+		}
+	}
+	visit(&document, false)
+	var result []string
+	for i, line := range lines {
+		if replaced, ok := folded[i]; ok {
+			result = append(result, replaced...)
+			continue
+		}
+		result = append(result, line)
+	}
+	return []byte(strings.Join(result, "\n")), nil
+}
+
+// foldScalar fold plain or quoted scalar written in one line, continuation lines are aligned to the scalar
+func foldScalar(lines []string, node *yaml.Node, width int, folded map[int][]string) {
+	line := lines[node.Line-1]
+	offset := columnOffset(line, node.Column)
+	text, err := renderScalar(node)
+	if err != nil || strings.Contains(text, "\n") || !strings.HasPrefix(line[offset:], text) {
+		return
+	}
+	breakable := func(i int) bool {
+		if text[i] != ' ' || i == 0 || i == len(text)-1 || text[i-1] == ' ' || text[i+1] == ' ' {
+			return false
+		}
+		switch node.Style & (yaml.SingleQuotedStyle | yaml.DoubleQuotedStyle) {
+		case yaml.DoubleQuotedStyle:
+			// escaped space is not folded
+			return text[i-1] != '\\'
+		case 0:
+			// continuation line of plain scalar could not start with indicators
+			return !strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(text[i+1]))
+		}
+		return true
+	}
+	indent := strings.Repeat(" ", utf8.RuneCountInString(line[:offset]))
+	if len(indent) >= width {
+		return
+	}
+	segments := foldText(text, utf8.RuneCountInString(line[:offset]), len(indent), width, breakable)
+	if len(segments) == 1 {
+		return
+	}
+	result := []string{line[:offset] + segments[0]}
+	for _, segment := range segments[1:] {
+		result = append(result, indent+segment)
+	}
+	result[len(result)-1] += line[offset+len(text):]
+	folded[node.Line-1] = result
+}
+
+// foldBlock fold lines of folded block scalar, lines which are more indented keep their line breaks
+func foldBlock(lines []string, node *yaml.Node, width int, folded map[int][]string) {
+	header := lines[node.Line-1][columnOffset(lines[node.Line-1], node.Column):]
+	if node.Value == "" || strings.ContainsAny(strings.SplitN(header, " ", 2)[0], "123456789") {
+		// the indentation is given by indicator, as the first line is more indented
+		return
+	}
+	indent := -1
+	for l := node.Line; l < len(lines); l++ {
+		text := lines[l]
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		spaces := len(text) - len(strings.TrimLeft(text, " "))
+		if indent < 0 {
+			indent = spaces
+		}
+		if spaces < indent {
+			return
+		}
+		if spaces > indent {
+			continue
+		}
+		body := text[indent:]
+		breakable := func(i int) bool {
+			return body[i] == ' ' && i > 0 && i < len(body)-1 && body[i-1] != ' ' && body[i+1] != ' '
+		}
+		segments := foldText(body, indent, indent, width, breakable)
+		if len(segments) == 1 {
+			continue
+		}
+		for i := range segments {
+			segments[i] = text[:indent] + segments[i]
+		}
+		folded[l] = segments
+	}
+}
+
+// foldText split text at breakable spaces, first is the width before text in its line, indent is the width of continuation lines
+func foldText(text string, first int, indent int, width int, breakable func(i int) bool) []string {
+	var segments []string
+	start, current, last := 0, first, -1
+	for i := 0; i < len(text); i++ {
+		if !breakable(i) {
+			continue
+		}
+		if last >= start && current+utf8.RuneCountInString(text[start:i]) > width {
+			segments = append(segments, text[start:last])
+			start, current = last+1, indent
+		}
+		last = i
+	}
+	if last >= start && current+utf8.RuneCountInString(text[start:]) > width {
+		segments = append(segments, text[start:last])
+		start = last + 1
+	}
+	return append(segments, text[start:])
+}
+
+// columnOffset return the byte offset of column (1-based, counted in characters) in line
+func columnOffset(line string, column int) int {
+	for offset := range line {
+		if column == 1 {
+			return offset
+		}
+		column--
+	}
+	return len(line)
+}
@@ -0,0 +1,150 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var encodeData = `# head
+a:
+  b: plain
+  c: "double"
+  d: 'single'
+  e: "123"
+  f:
+    - g: 1
+      h: [1, 2]
+    - |
+      literal
+long: this is a long string which is written in one line however long it is
+`
+
+func TestMarshalWith(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(encodeData))
+	out, err := yq.MarshalWith(yquery.MarshalOptions{
+		Indent:          2,
+		CompactSequence: true,
+		QuoteStyle:      yquery.DoubleQuotedStyle,
+		DocumentStart:   true,
+	})
+	asserts.NoError(err)
+	// language=yaml
+	asserts.Equal(`---
+# head
+a:
+  b: plain
+  c: "double"
+  d: "single"
+  e: "123"
+  f:
+  - g: 1
+    h: [1, 2]
+  - |
+    literal
+long: this is a long string which is written in one line however long it is
+`, string(out))
+
+	out, err = yq.MarshalWith(yquery.MarshalOptions{QuoteStyle: yquery.SingleQuotedStyle})
+	asserts.NoError(err)
+	// language=yaml
+	asserts.Equal(`# head
+a:
+    b: plain
+    c: 'double'
+    d: 'single'
+    e: '123'
+    f:
+        - g: 1
+          h: [1, 2]
+        - |
+          literal
+long: this is a long string which is written in one line however long it is
+`, string(out))
+	parsed, err := yquery.Unmarshal(out)
+	asserts.NoError(err)
+	v, _ := parsed.Get("long")
+	asserts.Equal("this is a long string which is written in one line however long it is", v)
+
+	out, err = yq.MarshalWith(yquery.MarshalOptions{})
+	asserts.NoError(err)
+	expected, _ := yq.Marshal()
+	asserts.Equal(string(expected), string(out))
+
+	_, err = yq.MarshalWith(yquery.MarshalOptions{QuoteStyle: yquery.LiteralStyle})
+	asserts.Error(err)
+	_, err = yq.MarshalWith(yquery.MarshalOptions{Indent: -1})
+	asserts.Error(err)
+}
+
+func TestMarshalWithLineWidth(t *testing.T) {
+	asserts := assert.New(t)
+	// language=yaml
+	data := `a:
+  plain: this is a long string which is written in one line # comment
+  quoted: "this is a long string which is written in one line\t"
+  list:
+    - this is a long string - which is written in one line
+    - {k: this is a long string which is written in one line}
+  folded: >
+    this is a long string which is written in one line
+
+    last line
+  literal: |
+    this is a long string which is written in one line
+this is a long key which is written in one line: a long value
+`
+	yq, _ := yquery.Unmarshal([]byte(data))
+	out, err := yq.MarshalWith(yquery.MarshalOptions{Indent: 2, LineWidth: 30})
+	asserts.NoError(err)
+	// language=yaml
+	asserts.Equal(`a:
+  plain: this is a long string
+         which is written in
+         one line # comment
+  quoted: "this is a long
+          string which is
+          written in one
+          line\t"
+  list:
+    - this is a long string -
+      which is written in one
+      line
+    - {k: this is a long string which is written in one line}
+  folded: >
+    this is a long string
+    which is written in one
+    line
+
+    last line
+
+  literal: |
+    this is a long string which is written in one line
+this is a long key which is written in one line: a long value
+`, string(out))
+	parsed, err := yquery.Unmarshal(out)
+	asserts.NoError(err)
+	changes, err := yquery.Diff(yq, parsed)
+	asserts.NoError(err)
+	asserts.Empty(changes)
+
+	_, err = yq.MarshalWith(yquery.MarshalOptions{LineWidth: -1})
+	asserts.Error(err)
+}
+
+func TestMarshalMergeKey(t *testing.T) {
+	asserts := assert.New(t)
+	data := "a: &a\n    k: 1\nb:\n    <<: *a\n    l: 2\nc:\n    !!merge <<: *a\n"
+	yq, _ := yquery.Unmarshal([]byte(data))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal(data, string(out))
+	raw, _ := yq.GetRaw("b")
+	asserts.Equal("<<: *a\nl: 2", raw)
+	v, _ := yq.Get("b.k")
+	asserts.Equal("1", v)
+}
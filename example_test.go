@@ -26,7 +26,7 @@ D: "*anchorA"
 // language=yaml
 var dataToSet = `newData: this is a new string`
 
-func ExampleGetInt() {
+func Example_getInt() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 
 	dataA, err := yq.Get("intA")
@@ -40,7 +40,7 @@ func ExampleGetInt() {
 	// 111
 }
 
-func ExampleGetString() {
+func Example_getString() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	dataB, _ := yq.Get("stringB")
 	rawB, _ := yq.GetRaw("stringB")
@@ -50,7 +50,7 @@ func ExampleGetString() {
 	// this is a string
 }
 
-func ExampleGetMapItem() {
+func Example_getMapItem() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	dataD, _ := yq.Get("mapC.intD")
 	rawDataD, _ := yq.GetRaw("mapC.intD")
@@ -60,7 +60,7 @@ func ExampleGetMapItem() {
 	// 222
 }
 
-func ExampleGetList() {
+func Example_getList() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	// list index starts from 0
 	dataF2, _ := yq.Get("mapC.listF[1]")
@@ -71,7 +71,7 @@ func ExampleGetList() {
 	// list item 2
 }
 
-func ExampleGetWithDelimiter() {
+func Example_getWithDelimiter() {
 	data := `
 example.com:
   admin: admin@example.com
@@ -82,7 +82,7 @@ example.com:
 	// Output: admin@example.com
 }
 
-func ExampleGetAnchorReference() {
+func Example_getAnchorReference() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	dataBinC, _ := yq.Get("C")
 	fmt.Println(dataBinC)
@@ -92,7 +92,7 @@ func ExampleGetAnchorReference() {
 	// *anchorA
 }
 
-func ExampleGetAnchorDefine() {
+func Example_getAnchorDefine() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	dataA, _ := yq.Get("A")
 	rawA, _ := yq.GetRaw("A")
@@ -105,7 +105,7 @@ func ExampleGetAnchorDefine() {
 	// B: string b
 }
 
-func ExampleGetValueInAnchor() {
+func Example_getValueInAnchor() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	dataAB, _ := yq.Get("A.B")
 	dataCB, _ := yq.Get("C.B")
@@ -115,7 +115,7 @@ func ExampleGetValueInAnchor() {
 	// string b
 }
 
-func ExampleGetAstString() {
+func Example_getAstString() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	// skip error handle
 	dataD, _ := yq.Get("D")
@@ -126,7 +126,7 @@ func ExampleGetAstString() {
 	// *anchorA
 }
 
-func ExampleSetInt() {
+func Example_setInt() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 
 	_ = yq.Set("intA", "333")
@@ -135,7 +135,7 @@ func ExampleSetInt() {
 	// Output: 333
 }
 
-func ExampleSetString() {
+func Example_setString() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	_ = yq.Set("stringB", "string modified")
 	dataB, _ := yq.Get("stringB")
@@ -143,7 +143,7 @@ func ExampleSetString() {
 	// Output: string modified
 }
 
-func ExampleSetAddItem() {
+func Example_setAddItem() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	_ = yq.Set("notExist", "new value")
 	newItem, _ := yq.Get("notExist")
@@ -151,7 +151,7 @@ func ExampleSetAddItem() {
 	// Output: new value
 }
 
-func ExampleSetMapItem() {
+func Example_setMapItem() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	_ = yq.Set("mapC.intD", "555")
 	dataD, _ := yq.Get("mapC.intD")
@@ -159,7 +159,7 @@ func ExampleSetMapItem() {
 	// Output: 555
 }
 
-func ExampleSetMapNewItem() {
+func Example_setMapNewItem() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	_ = yq.Set("mapC.newItem", "555")
 	newItem, _ := yq.Get("mapC.newItem")
@@ -167,7 +167,7 @@ func ExampleSetMapNewItem() {
 	// Output: 555
 }
 
-func ExampleSetList() {
+func Example_setList() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	_ = yq.Set("mapC.listF[0]", "item to be 0")
 	dataF1, _ := yq.Get("mapC.listF[0]")
@@ -175,7 +175,7 @@ func ExampleSetList() {
 	// Output: item to be 0
 }
 
-func ExampleSetAddStruct() {
+func Example_setAddStruct() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	_ = yq.Set("G", dataToSet)
 	GNewData, _ := yq.Get("G.newData")
@@ -183,7 +183,7 @@ func ExampleSetAddStruct() {
 	// Output: this is a new string
 }
 
-func ExampleSetListNewItem() {
+func Example_setListNewItem() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	_ = yq.Set("mapC.listF[2]", "new item 3")
 	dataF3, _ := yq.Get("mapC.listF[2]")
@@ -191,7 +191,7 @@ func ExampleSetListNewItem() {
 	// Output: new item 3
 }
 
-func ExampleSetAnchor() {
+func Example_setAnchor() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	// skip error handle
 	_ = yq.Set("A.B", "new b")
@@ -203,7 +203,7 @@ func ExampleSetAnchor() {
 	// new b
 }
 
-func ExampleSetAnchorReferenceError() {
+func Example_setAnchorReferenceError() {
 	yq, _ := yquery.Unmarshal([]byte(exampleData))
 	// skip error handle
 	err := yq.Set("C.B", "new b")
@@ -0,0 +1,169 @@
+package yquery
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Explode replace every anchor reference with a copy of the anchor, and resolve every merge item into concrete keys
+// The result contains no anchor, alias or merge key, but reads the same via Get.
+//     a: &anchorA
+//       b: data of b
+//     c: *anchorA
+//     d:
+//       <<: *anchorA
+//       e: 1
+//
+// After Explode, the data above become
+//     a:
+//       b: data of b
+//     c:
+//       b: data of b
+//     d:
+//       b: data of b
+//       e: 1
+//
+// Keys defined directly in the node override keys come from merge, same as Get does.
+func (y *YQuery) Explode() error {
+	return y.modify(Operation{Name: "Explode"}, func() error {
+		node, err := explodeNode(y.RootNode, nil)
+		if err != nil {
+			return err
+		}
+		y.RootNode = node
+		return nil
+	})
+}
+
+// ExplodeAt is similar to Explode, but only explode the node of the parser string
+// Anchors defined inside the node are removed as well,
+// therefore anchor references outside the node pointing to them are exploded too, to keep the document valid.
+// The node itself could not be inside an anchor reference or a merge item.
+func (y *YQuery) ExplodeAt(parser string, config ...Config) error {
+	return y.modify(Operation{Name: "ExplodeAt", Paths: []string{parser}}, func() error {
+		delimiter, err := getDelimiter(config)
+		if err != nil {
+			return err
+		}
+		parent, index, err := y.locate(getParserSlice(parser, delimiter), delimiter, false)
+		if err != nil {
+			return err
+		}
+		node, err := explodeNode(parent.Content[index], nil)
+		if err != nil {
+			return err
+		}
+		parent.Content[index] = node
+		return y.explodeDangling()
+	})
+}
+
+// explodeDangling explode anchor references whose anchor no longer exists in the document
+func (y *YQuery) explodeDangling() error {
+	anchors := map[*yaml.Node]bool{}
+	walkNode(y.RootNode, func(node *yaml.Node) {
+		if node.Anchor != "" {
+			anchors[node] = true
+		}
+	})
+	var err error
+	walkNode(y.RootNode, func(node *yaml.Node) {
+		for i, content := range node.Content {
+			if err != nil || content.Alias == nil || anchors[content.Alias] {
+				continue
+			}
+			node.Content[i], err = explodeNode(content, nil)
+		}
+	})
+	return err
+}
+
+// explodeNode return a exploded copy of node, nil for nil node
+// stack holds nodes being exploded, to find recursive anchor reference.
+func explodeNode(node *yaml.Node, stack []*yaml.Node) (*yaml.Node, error) {
+	if node == nil {
+		return nil, nil
+	}
+	for _, n := range stack {
+		if n == node {
+			return nil, fmt.Errorf("cannot explode recursive anchor reference '%s'", node.Anchor)
+		}
+	}
+	stack = append(stack, node)
+	if node.Alias != nil {
+		return explodeNode(node.Alias, stack)
+	}
+	result := *node
+	result.Anchor = ""
+	if node.Content == nil {
+		return &result, nil
+	}
+	result.Content = make([]*yaml.Node, 0, len(node.Content))
+	if node.Kind != yaml.MappingNode {
+		for _, content := range node.Content {
+			exploded, err := explodeNode(content, stack)
+			if err != nil {
+				return nil, err
+			}
+			result.Content = append(result.Content, exploded)
+		}
+		return &result, nil
+	}
+
+	// merged value of each key, the one with highest precedence wins
+	merged := map[string]*yaml.Node{}
+	for _, source := range mergeSources(node) {
+		exploded, err := explodeNode(source, stack)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(exploded.Content); i += 2 {
+			if _, ok := merged[exploded.Content[i].Value]; !ok {
+				merged[exploded.Content[i].Value] = exploded.Content[i+1]
+			}
+		}
+	}
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		if node.Content[index].Tag != mergeTag {
+			delete(merged, node.Content[index].Value)
+		}
+	}
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		if node.Content[index].Tag != mergeTag {
+			key, err := explodeNode(node.Content[index], stack)
+			if err != nil {
+				return nil, err
+			}
+			value, err := explodeNode(node.Content[index+1], stack)
+			if err != nil {
+				return nil, err
+			}
+			result.Content = append(result.Content, key, value)
+			continue
+		}
+		// merged keys are placed where the merge key is, in the order they are defined
+		source, err := explodeNode(node.Content[index+1], stack)
+		if err != nil {
+			return nil, err
+		}
+		mappings := []*yaml.Node{source}
+		if source.Kind == yaml.SequenceNode {
+			mappings = source.Content
+		}
+		for _, mapping := range mappings {
+			if mapping.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("merge item at line %d is not a mapping", node.Content[index+1].Line)
+			}
+			for i := 0; i+1 < len(mapping.Content); i += 2 {
+				value, ok := merged[mapping.Content[i].Value]
+				if !ok {
+					continue
+				}
+				result.Content = append(result.Content, mapping.Content[i], value)
+				delete(merged, mapping.Content[i].Value)
+			}
+		}
+	}
+	return &result, nil
+}
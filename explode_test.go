@@ -0,0 +1,57 @@
+package yquery_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+var explodeParsers = []string{
+	"a", "c", "c.d", "f", "f.e", "g.h[1]", "j.h[0]", "j.h2[1]", "j.i", "j.k", "l", "n[2][1]",
+}
+
+func TestExplode(t *testing.T) {
+	asserts := assert.New(t)
+	origin, _ := yquery.Unmarshal([]byte(data))
+	exploded, _ := yquery.Unmarshal([]byte(data))
+	asserts.NoError(exploded.Explode())
+	for _, parser := range explodeParsers {
+		expected, err := origin.Get(parser)
+		asserts.NoError(err)
+		res, err := exploded.Get(parser)
+		asserts.NoError(err)
+		asserts.Equal(expected, res, parser)
+	}
+	out, err := exploded.Marshal()
+	asserts.NoError(err)
+	asserts.NotContains(string(out), "&")
+	asserts.NotContains(string(out), "*")
+	asserts.NotContains(string(out), "<<")
+}
+
+func TestExplodeAt(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	asserts.NoError(yq.ExplodeAt("j"))
+	res, _ := yq.GetRaw("j")
+	asserts.False(strings.Contains(res, "<<"))
+	res, _ = yq.Get("j.h[0]")
+	asserts.Equal("ui1", res)
+	// anchor cPtr is removed, reference f should be exploded as well
+	asserts.NoError(yq.ExplodeAt("c"))
+	res, _ = yq.GetRaw("f")
+	asserts.Equal("# comment in c\nd: \"d in c\"\ne: \"e in c\"", res)
+	res, _ = yq.GetRaw("g")
+	asserts.Contains(res, "&gAnchor")
+
+	asserts.Error(yq.ExplodeAt("notExist"))
+}
+
+func TestExplodeRecursive(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte("a: &a\n  b: *a\n"))
+	asserts.Error(yq.Explode())
+}
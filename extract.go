@@ -0,0 +1,241 @@
+package yquery
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultMinSize is the default MinSize of ExtractAnchorsConfig
+const defaultMinSize = 5
+
+// ExtractAnchorsConfig is the optional parameter for ExtractAnchors
+type ExtractAnchorsConfig struct {
+	// MinSize is the minimum number of nodes (keys, values and items) of a subtree to be extracted, default is 5
+	// For example, `{a: 1, b: 2}` has 5 nodes: the mapping, 2 keys and 2 values.
+	MinSize int
+	// Merge could be set to true to extract near-duplicated mappings with merge key
+	// A mapping having all keys of a previous mapping, with some values changed or some keys added,
+	// is replaced by a merge of the previous one, plus the items different from it.
+	Merge bool
+}
+
+// ExtractAnchors find identical subtrees, define an anchor on the first one, and replace others with anchor references
+//     a:
+//       image: web
+//       port: 80
+//     b:
+//       image: web
+//       port: 80
+//
+// After ExtractAnchors, the data above become
+//     a: &a
+//       image: web
+//       port: 80
+//     b: *a
+//
+// The anchor is named after the key of the first subtree. Subtrees defining anchors are not replaced.
+// Get returns the same data after it, comments inside replaced subtrees are removed.
+func (y *YQuery) ExtractAnchors(config ...ExtractAnchorsConfig) error {
+	if len(config) > 1 {
+		return fmt.Errorf("extract anchors could only get 0 or 1 config, got %d", len(config))
+	}
+	c := ExtractAnchorsConfig{}
+	if len(config) == 1 {
+		c = config[0]
+	}
+	if c.MinSize <= 0 {
+		c.MinSize = defaultMinSize
+	}
+	return y.modify(Operation{Name: "ExtractAnchors"}, func() error {
+		e := extractor{
+			config:       c,
+			first:        map[string]*yaml.Node{},
+			anchors:      map[string]bool{},
+			names:        map[*yaml.Node]string{},
+			fingerprints: map[*yaml.Node]string{},
+			sizes:        map[*yaml.Node]int{},
+			anchored:     map[*yaml.Node]bool{},
+		}
+		walkNode(y.RootNode, func(node *yaml.Node) {
+			if node.Anchor != "" {
+				e.anchors[node.Anchor] = true
+			}
+		})
+		if y.RootNode != nil {
+			e.measure(y.RootNode)
+			e.extract(nil, y.RootNode)
+		}
+		return nil
+	})
+}
+
+type extractor struct {
+	config ExtractAnchorsConfig
+	// first is the first subtree of each fingerprint
+	first map[string]*yaml.Node
+	// mappings are the mappings visited, candidates of merge
+	mappings []*yaml.Node
+	// anchors are names of anchors in the document
+	anchors map[string]bool
+	// names are suggested anchor names of subtrees visited
+	names map[*yaml.Node]string
+	// ancestors are the nodes being visited
+	ancestors []*yaml.Node
+	// fingerprints, sizes and anchored are measured once before subtrees are replaced, see measure
+	fingerprints map[*yaml.Node]string
+	sizes        map[*yaml.Node]int
+	anchored     map[*yaml.Node]bool
+}
+
+// measure record the fingerprint, the number of nodes, and whether an anchor is defined, of node and all its descendant
+// Fingerprint is the same for structurally identical nodes. Comments, styles and anchors are ignored,
+// anchor references are the same if they refer to the same anchor.
+func (e *extractor) measure(node *yaml.Node) {
+	hash := sha256.New()
+	switch node.Kind {
+	case yaml.AliasNode:
+		fmt.Fprintf(hash, "*%p", node.Alias)
+	case yaml.ScalarNode:
+		fmt.Fprintf(hash, "%s%q", node.ShortTag(), node.Value)
+	default:
+		fmt.Fprintf(hash, "%s%d", node.ShortTag(), len(node.Content))
+	}
+	size, anchored := 1, node.Anchor != ""
+	for _, content := range node.Content {
+		e.measure(content)
+		hash.Write([]byte(e.fingerprints[content]))
+		size += e.sizes[content]
+		anchored = anchored || e.anchored[content]
+	}
+	e.fingerprints[node] = string(hash.Sum(nil))
+	e.sizes[node] = size
+	e.anchored[node] = anchored
+}
+
+// extract visit node in the order of document, return the node replacing it
+// name is the key of the node, or nil for sequence item and root.
+func (e *extractor) extract(name *yaml.Node, node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode {
+		return node
+	}
+	hasAnchor := e.anchored[node]
+	if e.sizes[node] >= e.config.MinSize {
+		fingerprint := e.fingerprints[node]
+		if first, ok := e.first[fingerprint]; ok && !hasAnchor {
+			alias := e.alias(first)
+			// the reference has the same data as the node replaced
+			e.fingerprints[alias], e.sizes[alias] = fingerprint, e.sizes[node]
+			return alias
+		}
+		if _, ok := e.first[fingerprint]; !ok {
+			e.first[fingerprint] = node
+			e.names[node] = anchorName(name)
+		}
+		if e.config.Merge && node.Kind == yaml.MappingNode && !hasAnchor {
+			e.mergeMapping(node)
+		}
+		if node.Kind == yaml.MappingNode {
+			e.mappings = append(e.mappings, node)
+		}
+	}
+	e.ancestors = append(e.ancestors, node)
+	for i, content := range node.Content {
+		switch {
+		case node.Kind != yaml.MappingNode:
+			node.Content[i] = e.extract(nil, content)
+		case i%2 == 1 && node.Content[i-1].Tag != mergeTag:
+			node.Content[i] = e.extract(node.Content[i-1], content)
+		}
+	}
+	e.ancestors = e.ancestors[:len(e.ancestors)-1]
+	return node
+}
+
+// alias return an anchor reference of node, anchor is defined if it does not exist
+func (e *extractor) alias(node *yaml.Node) *yaml.Node {
+	if node.Anchor == "" {
+		name := e.names[node]
+		for i := 2; e.anchors[name]; i++ {
+			name = fmt.Sprintf("%s_%d", e.names[node], i)
+		}
+		node.Anchor = name
+		e.anchors[name] = true
+	}
+	return &yaml.Node{Kind: yaml.AliasNode, Value: node.Anchor, Alias: node}
+}
+
+// mergeMapping replace items of mapping which are the same as a previous mapping with a merge of it
+// The previous mapping sharing most nodes is used.
+func (e *extractor) mergeMapping(mapping *yaml.Node) {
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Tag == mergeTag {
+			return
+		}
+	}
+	var base *yaml.Node
+	shared := 0
+	for _, candidate := range e.mappings {
+		if size := e.sharedSize(candidate, mapping); size > shared && !e.isAncestor(candidate) {
+			base, shared = candidate, size
+		}
+	}
+	if base == nil || shared < e.config.MinSize {
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: mergeTag, Value: "<<"}
+	content := []*yaml.Node{key, e.alias(base)}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		value := mappingValue(base, mapping.Content[i].Value)
+		if value == nil || e.fingerprints[value] != e.fingerprints[mapping.Content[i+1]] {
+			content = append(content, mapping.Content[i], mapping.Content[i+1])
+		}
+	}
+	mapping.Content = content
+}
+
+func (e *extractor) isAncestor(node *yaml.Node) bool {
+	for _, ancestor := range e.ancestors {
+		if ancestor == node {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedSize return the number of nodes of items in mapping which are the same in base
+// It returns 0 if base has merge key, or has keys not in mapping.
+func (e *extractor) sharedSize(base *yaml.Node, mapping *yaml.Node) int {
+	shared := 0
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		value := mappingValue(mapping, base.Content[i].Value)
+		if base.Content[i].Tag == mergeTag || value == nil {
+			return 0
+		}
+		if e.fingerprints[value] == e.fingerprints[base.Content[i+1]] {
+			shared += e.sizes[value] + 1
+		}
+	}
+	if shared > 0 {
+		// the merge key and anchor reference replacing them
+		shared++
+	}
+	return shared
+}
+
+var invalidAnchorCharacters = regexp.MustCompile(`[^0-9A-Za-z_-]+`)
+
+// anchorName return a valid anchor name from key
+func anchorName(key *yaml.Node) string {
+	name := ""
+	if key != nil {
+		name = strings.Trim(invalidAnchorCharacters.ReplaceAllString(key.Value, "_"), "_")
+	}
+	if name == "" {
+		return "anchor"
+	}
+	return name
+}
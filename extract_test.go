@@ -0,0 +1,91 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var extractData = `web:
+  image: nginx
+  ports: [80, 443]
+api:
+  # comment removed
+  image: nginx
+  ports: [80, 443]
+jobs:
+  - image: nginx
+    ports: [80, 443]
+  - image: nginx
+    ports: [80, 443]
+    replicas: 3
+base: &base
+  x: 1
+small: {x: 1}
+copy:
+  x: 1
+`
+
+func TestExtractAnchors(t *testing.T) {
+	asserts := assert.New(t)
+	origin, _ := yquery.Unmarshal([]byte(extractData))
+	yq, _ := yquery.Unmarshal([]byte(extractData))
+	asserts.NoError(yq.ExtractAnchors())
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	// language=yaml
+	asserts.Equal(`web: &web
+    image: nginx
+    ports: [80, 443]
+api: *web
+jobs:
+    - *web
+    - image: nginx
+      ports: [80, 443]
+      replicas: 3
+base: &base
+    x: 1
+small: {x: 1}
+copy:
+    x: 1
+`, string(out))
+	changes, err := yquery.Diff(origin, yq)
+	asserts.NoError(err)
+	asserts.Empty(changes)
+}
+
+func TestExtractAnchorsMerge(t *testing.T) {
+	asserts := assert.New(t)
+	origin, _ := yquery.Unmarshal([]byte(extractData))
+	yq, _ := yquery.Unmarshal([]byte(extractData))
+	asserts.NoError(yq.ExtractAnchors(yquery.ExtractAnchorsConfig{MinSize: 3, Merge: true}))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	// language=yaml
+	asserts.Equal(`web: &web
+    image: nginx
+    ports: [80, 443]
+api: *web
+jobs:
+    - *web
+    - <<: *web
+      replicas: 3
+base: &base
+    x: 1
+small: *base
+copy: *base
+`, string(out))
+	changes, err := yquery.Diff(origin, yq)
+	asserts.NoError(err)
+	asserts.Empty(changes)
+	v, _ := yq.Get("jobs[1].ports[1]")
+	asserts.Equal("443", v)
+	reloaded, err := yquery.Unmarshal(out)
+	asserts.NoError(err)
+	changes, err = yquery.Diff(origin, reloaded)
+	asserts.NoError(err)
+	asserts.Empty(changes)
+}
@@ -0,0 +1,118 @@
+package yquery
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// defaultFileMode is the mode of file created by SaveAs
+const defaultFileMode os.FileMode = 0644
+
+// Load read and unmarshal the yaml file of path
+// The format of the file is detected, and kept when it is written by Save, see LoadReader.
+func Load(path string) (*YQuery, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	y, err := LoadReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load %s: %s", path, err)
+	}
+	y.path = path
+	return y, nil
+}
+
+// LoadReader read and unmarshal yaml from reader, and detect its format
+// Detected format includes indentation width, whether sequences in mapping are indented, line endings
+// and whether it ends with a newline. It is used by Save and SaveAs, while Marshal always use the format of go-yaml.
+func LoadReader(reader io.Reader) (*YQuery, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	y, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	f := detectFormat(data, y.RootNode)
+	y.format = &f
+	return y, nil
+}
+
+// SaveConfig is the optional parameter for Save and SaveAs
+type SaveConfig struct {
+	// MinimalDiff could be set to true to change only lines of modified items in the file, see MarshalMinimal
+	MinimalDiff bool
+}
+
+// Save write the document back to the file it is loaded from, or saved to by SaveAs
+func (y *YQuery) Save(config ...SaveConfig) error {
+	if y.path == "" {
+		return fmt.Errorf("the document is not loaded from a file, use SaveAs instead")
+	}
+	return y.SaveAs(y.path, config...)
+}
+
+// SaveAs write the document to the file of path, in the format detected when it is loaded
+// The file is written atomically: data is written to a temporary file in the same directory, then renamed to path.
+// Mode of the existing file is kept. Following Save writes to path.
+func (y *YQuery) SaveAs(path string, config ...SaveConfig) error {
+	if len(config) > 1 {
+		return fmt.Errorf("save could only get 0 or 1 config, got %d", len(config))
+	}
+	var out []byte
+	var err error
+	if len(config) == 1 && config[0].MinimalDiff {
+		out, err = y.MarshalMinimal()
+	} else {
+		f := defaultFormat
+		if y.format != nil {
+			f = *y.format
+		}
+		out, err = y.encode(f)
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, out); err != nil {
+		return err
+	}
+	y.path = path
+	y.source = out
+	return nil
+}
+
+// writeFileAtomic write data to a temporary file and rename it to path, keeping mode of the existing file
+func writeFileAtomic(path string, data []byte) error {
+	mode := defaultFileMode
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
@@ -0,0 +1,69 @@
+package yquery_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+func TestLoadAndSave(t *testing.T) {
+	asserts := assert.New(t)
+	dir, err := ioutil.TempDir("", "yquery")
+	asserts.NoError(err)
+	defer os.RemoveAll(dir)
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"indent 2", "a:\n  b: 1\n  c:\n    - 1\n    - d: 2\n      e: 3\n"},
+		{"compact sequence", "a:\n  b:\n  - 1\n  - |\n    literal\n  - c:\n    - 2\n  d: 3\n# foot\n"},
+		{"crlf without trailing newline", "a:\r\n   b: 1\r\n   c: [1, 2]"},
+		{"flat", "a: 1\nb: [1]\n"},
+	}
+	for _, c := range testCases {
+		path := filepath.Join(dir, "test.yaml")
+		asserts.NoError(ioutil.WriteFile(path, []byte(c.data), 0600))
+		yq, err := yquery.Load(path)
+		asserts.NoError(err, c.name)
+		asserts.NoError(yq.Save(), c.name)
+		out, _ := ioutil.ReadFile(path)
+		asserts.Equal(c.data, string(out), c.name)
+		info, _ := os.Stat(path)
+		asserts.Equal(os.FileMode(0600), info.Mode(), c.name)
+	}
+
+	yq, _ := yquery.Load(filepath.Join(dir, "test.yaml"))
+	asserts.NoError(yq.Set("c.d", "1", yquery.Config{Recursive: true}))
+	asserts.NoError(yq.Save())
+	out, _ := ioutil.ReadFile(filepath.Join(dir, "test.yaml"))
+	asserts.Equal("a: 1\nb: [1]\nc:\n    d: 1\n", string(out))
+
+	files, _ := ioutil.ReadDir(dir)
+	asserts.Len(files, 1)
+
+	_, err = yquery.Load(filepath.Join(dir, "missing.yaml"))
+	asserts.Error(err)
+}
+
+func TestSaveAs(t *testing.T) {
+	asserts := assert.New(t)
+	dir, err := ioutil.TempDir("", "yquery")
+	asserts.NoError(err)
+	defer os.RemoveAll(dir)
+	yq, _ := yquery.LoadReader(bytes.NewBufferString("a:\n  - b: 1\n"))
+	asserts.Error(yq.Save())
+	path := filepath.Join(dir, "new.yaml")
+	asserts.NoError(yq.SaveAs(path))
+	asserts.NoError(yq.Set("a[0].c", "2"))
+	asserts.NoError(yq.Save())
+	out, _ := ioutil.ReadFile(path)
+	asserts.Equal("a:\n  - b: 1\n    c: 2\n", string(out))
+	info, _ := os.Stat(path)
+	asserts.Equal(os.FileMode(0644), info.Mode())
+}
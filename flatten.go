@@ -0,0 +1,238 @@
+package yquery
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IndexStyle is the way to write sequence index in flattened path
+type IndexStyle int
+
+const (
+	// BracketIndex write index in bracket after the key, e.g. "a.b[0].c", the same as Get
+	BracketIndex IndexStyle = iota
+	// DelimitedIndex write index as a key, e.g. "a.b.0.c", or "A_B_0_C" with delimiter "_"
+	DelimitedIndex
+)
+
+// KeyCase is the case of keys in flattened path
+type KeyCase int
+
+const (
+	// KeepCase write keys as they are
+	KeepCase KeyCase = iota
+	// UpperCase write keys in upper case, e.g. for environment variables
+	UpperCase
+	// LowerCase write keys in lower case
+	LowerCase
+)
+
+
+// FlattenOptions is the optional parameter for Flatten and Unflatten
+type FlattenOptions struct {
+	// Delimiter separates keys in path, default is "."
+	Delimiter string
+	// Index is the way to write sequence index
+	Index IndexStyle
+	// Case is the case of keys, Unflatten keeps keys as they are in path
+	Case KeyCase
+}
+
+// FlatItem is a path and the value of a leaf item
+// Value is a yaml scalar, quoted if it is a string that looks like other types, e.g. `"8080"`.
+type FlatItem struct {
+	Path  string
+	Value string
+}
+
+// FlatItems is a list of FlatItem, in the order of the document
+type FlatItems []FlatItem
+
+// String write items in "path=value" lines
+func (items FlatItems) String() string {
+	var builder strings.Builder
+	for _, item := range items {
+		builder.WriteString(item.Path + "=" + item.Value + "\n")
+	}
+	return builder.String()
+}
+
+// ParseFlatItems read items from "path=value" lines, empty lines and lines starting with "#" are skipped
+func ParseFlatItems(text string) (FlatItems, error) {
+	var items FlatItems
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.Index(text, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d is not 'path=value': %s", line, text)
+		}
+		items = append(items, FlatItem{Path: strings.TrimSpace(text[:i]), Value: strings.TrimSpace(text[i+1:])})
+	}
+	return items, scanner.Err()
+}
+
+// Flatten return the path and value of each leaf item of the document
+//     a:
+//       b: [1, "2"]
+//
+// Flatten results
+//     a.b[0]=1
+//     a.b[1]="2"
+//
+// Anchor references and merge keys are resolved. Empty mappings and sequences are leaves with value {} and [].
+// With default options, paths could be used in Get. Empty keys and keys containing the delimiter, "[" or "=" are not able to be
+// written in path, nor keys of digits with DelimitedIndex, which are taken as index by Unflatten.
+// An error is returned for them. The root must be a mapping or sequence.
+func (y *YQuery) Flatten(options ...FlattenOptions) (FlatItems, error) {
+	o, err := getFlattenOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	if y.RootNode == nil {
+		return nil, nil
+	}
+	node, err := explodeNode(y.RootNode, nil)
+	if err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("the root of document must be a mapping or sequence to flatten, got %s", node.ShortTag())
+	}
+	f := flattener{options: o}
+	if err := f.flatten("", node); err != nil {
+		return nil, err
+	}
+	return f.items, nil
+}
+
+func getFlattenOptions(options []FlattenOptions) (FlattenOptions, error) {
+	if len(options) > 1 {
+		return FlattenOptions{}, fmt.Errorf("flatten could only get 0 or 1 options, got %d", len(options))
+	}
+	o := FlattenOptions{}
+	if len(options) == 1 {
+		o = options[0]
+	}
+	delimiter, err := getDelimiter([]Config{{Delimiter: o.Delimiter}})
+	if err != nil {
+		return o, err
+	}
+	o.Delimiter = delimiter
+	return o, nil
+}
+
+type flattener struct {
+	options FlattenOptions
+	items   FlatItems
+}
+
+// flatten add leaf items of node, path is the flattened path of node
+func (f *flattener) flatten(path string, node *yaml.Node) error {
+	if len(node.Content) == 0 || (node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode) {
+		value, err := flatValue(node)
+		if err != nil {
+			return fmt.Errorf("cannot flatten item '%s': %s", path, err)
+		}
+		f.items = append(f.items, FlatItem{Path: path, Value: value})
+		return nil
+	}
+	if node.Kind == yaml.SequenceNode {
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if f.options.Index == DelimitedIndex {
+				itemPath = f.join(path, fmt.Sprint(i))
+			}
+			if err := f.flatten(itemPath, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if node.Content[i].Kind != yaml.ScalarNode || key == "" || strings.Contains(key, f.options.Delimiter) || strings.ContainsAny(key, "[=") {
+			return fmt.Errorf("key '%s' in '%s' cannot be written in path", key, path)
+		}
+		if f.options.Index == DelimitedIndex && isFlatIndex(key) {
+			return fmt.Errorf("key '%s' in '%s' cannot be written in path, it would be taken as index", key, path)
+		}
+		switch f.options.Case {
+		case UpperCase:
+			key = strings.ToUpper(key)
+		case LowerCase:
+			key = strings.ToLower(key)
+		}
+		if err := f.flatten(f.join(path, key), node.Content[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *flattener) join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + f.options.Delimiter + key
+}
+
+// flatValue write leaf node as a one line yaml value
+func flatValue(node *yaml.Node) (string, error) {
+	node = copyNode(node)
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+	normalizeStyle(node)
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSuffix(string(out), "\n")
+	if strings.Contains(value, "\n") {
+		// block scalar, write it in double quote
+		node.Style = node.Style&yaml.TaggedStyle | yaml.DoubleQuotedStyle
+		out, err = yaml.Marshal(node)
+		value = strings.TrimSuffix(string(out), "\n")
+	}
+	return value, err
+}
+
+// Unflatten build a document from items flattened with the same options
+// Values are parsed as yaml, so that types are the same as the flattened document.
+// With DelimitedIndex, keys of digits are taken as sequence index.
+// Items of a sequence should be in the order of index, missing items are filled with null as PadSequence of Set does,
+// so an index larger than 10000 is an error.
+func Unflatten(items FlatItems, options ...FlattenOptions) (*YQuery, error) {
+	o, err := getFlattenOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	y := &YQuery{document: yaml.Node{Kind: yaml.DocumentNode}}
+	for _, item := range items {
+		path := item.Path
+		if o.Index == DelimitedIndex {
+			slices := strings.Split(path, o.Delimiter)
+			for i, slice := range slices {
+				if isFlatIndex(slice) {
+					slices[i] = "[" + slice + "]"
+				}
+			}
+			path = strings.Replace(strings.Join(slices, o.Delimiter), o.Delimiter+"[", "[", -1)
+		}
+		err := y.Set(path, item.Value, Config{Delimiter: o.Delimiter, Recursive: true, PadSequence: true})
+		if err != nil {
+			return nil, fmt.Errorf("cannot set item '%s': %s", item.Path, err)
+		}
+	}
+	return y, nil
+}
+
+// isFlatIndex return true if slice of path is an index with DelimitedIndex
+func isFlatIndex(slice string) bool {
+	return slice != "" && strings.Trim(slice, "0123456789") == ""
+}
@@ -0,0 +1,125 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var flattenData = `
+base: &base
+  host: localhost
+  port: 8080
+app:
+  <<: *base
+  version: "1.0"
+  tags: [web, "true"]
+  message: |
+    hello
+    world
+  empty: {}
+  nothing: null
+`
+
+func TestFlatten(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(flattenData))
+	items, err := yq.Flatten()
+	asserts.NoError(err)
+	asserts.Equal(`base.host=localhost
+base.port=8080
+app.host=localhost
+app.port=8080
+app.version="1.0"
+app.tags[0]=web
+app.tags[1]="true"
+app.message="hello\nworld\n"
+app.empty={}
+app.nothing=null
+`, items.String())
+	for _, item := range items {
+		_, err := yq.Get(item.Path)
+		asserts.NoError(err)
+	}
+
+	back, err := yquery.Unflatten(items)
+	asserts.NoError(err)
+	expected, _ := yq.Flatten()
+	actual, err := back.Flatten()
+	asserts.NoError(err)
+	asserts.Equal(expected, actual)
+	res, _ := back.Get("app.message")
+	asserts.Equal("hello\nworld\n", res)
+}
+
+func TestFlattenEnv(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte("db:\n  hosts:\n    - name: a\n      port: 5432\n"))
+	options := yquery.FlattenOptions{Delimiter: "_", Index: yquery.DelimitedIndex, Case: yquery.UpperCase}
+	items, err := yq.Flatten(options)
+	asserts.NoError(err)
+	asserts.Equal("DB_HOSTS_0_NAME=a\nDB_HOSTS_0_PORT=5432\n", items.String())
+
+	items, err = yquery.ParseFlatItems("# env\nDB_HOSTS_0_NAME=a\n\nDB_HOSTS_1_PORT = 5432\n")
+	asserts.NoError(err)
+	back, err := yquery.Unflatten(items, options)
+	asserts.NoError(err)
+	out, _ := back.Marshal()
+	asserts.Equal("DB:\n    HOSTS:\n        - NAME: a\n        - PORT: 5432\n", string(out))
+
+	_, err = yquery.ParseFlatItems("no value\n")
+	asserts.Error(err)
+}
+
+func TestFlattenError(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte("a.b: 1\n"))
+	_, err := yq.Flatten()
+	asserts.Error(err)
+	_, err = yq.Flatten(yquery.FlattenOptions{Delimiter: "/"})
+	asserts.NoError(err)
+
+	scalar, _ := yquery.Unmarshal([]byte("a\n"))
+	_, err = scalar.Flatten()
+	asserts.Error(err)
+
+	equal, _ := yquery.Unmarshal([]byte("a=b: 1\n"))
+	_, err = equal.Flatten()
+	asserts.Error(err)
+
+	// empty keys would be blank items or empty slices of path
+	for _, data := range []string{"\"\": 1\n", "a:\n  \"\": 2\n"} {
+		empty, _ := yquery.Unmarshal([]byte(data))
+		_, err = empty.Flatten()
+		asserts.Error(err, data)
+	}
+
+	// digit keys would be taken as index by Unflatten
+	ports, _ := yquery.Unmarshal([]byte("ports:\n  \"8080\": web\n"))
+	items, err := ports.Flatten()
+	asserts.NoError(err)
+	asserts.Equal(yquery.FlatItems{{Path: "ports.8080", Value: "web"}}, items)
+	_, err = ports.Flatten(yquery.FlattenOptions{Index: yquery.DelimitedIndex})
+	asserts.Error(err)
+
+	_, err = yquery.Unflatten(yquery.FlatItems{{Path: "A_99999999", Value: "x"}}, yquery.FlattenOptions{Delimiter: "_", Index: yquery.DelimitedIndex})
+	asserts.Error(err)
+	_, err = yquery.Unflatten(yquery.FlatItems{{Path: "a[10001]", Value: "x"}})
+	asserts.Error(err)
+	padded, err := yquery.Unflatten(yquery.FlatItems{{Path: "a[2]", Value: "x"}})
+	asserts.NoError(err)
+	out, _ := padded.Marshal()
+	asserts.Equal("a:\n    - null\n    - null\n    - x\n", string(out))
+
+	root, _ := yquery.Unmarshal([]byte("- a: 1\n"))
+	items, err = root.Flatten()
+	asserts.NoError(err)
+	asserts.Equal(yquery.FlatItems{{Path: "[0].a", Value: "1"}}, items)
+	back, err := yquery.Unflatten(items)
+	asserts.NoError(err)
+	out, _ = back.Marshal()
+	asserts.Equal("- a: 1\n", string(out))
+}
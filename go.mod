@@ -3,6 +3,7 @@ module github.com/sixleaveakkm/yquery
 go 1.12
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/stretchr/testify v1.4.0
-	gopkg.in/yaml.v3 v3.0.0-20190709130402-674ba3eaed22
+	gopkg.in/yaml.v3 v3.0.1
 )
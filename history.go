@@ -0,0 +1,129 @@
+package yquery
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Operation is a record of a modification of the document
+type Operation struct {
+	// Name is the name of the method modifies the document, e.g. "Set", "Delete"
+	Name string
+	// Paths are the parser strings of items affected
+	Paths []string
+}
+
+type historyEntry struct {
+	Operation
+	// root is the document before the operation in undo list, or after the operation in redo list
+	root *yaml.Node
+	// document holds comments of the document at the same time
+	document yaml.Node
+}
+
+type history struct {
+	depth int
+	undo  []historyEntry
+	redo  []historyEntry
+	// transaction is set for the history of a transaction
+	transaction bool
+	// operations holds all operations of the transaction regardless of depth, their paths are recorded by Commit
+	operations []Operation
+}
+
+// SetHistoryDepth enable undo and redo, and keep at most depth operations
+// History is disabled by default, set depth to 0 disable it and clear all history.
+// A copy of the whole document is kept for each operation, consider the size of document when choosing depth.
+func (y *YQuery) SetHistoryDepth(depth int) {
+	if depth <= 0 {
+		y.history = nil
+		return
+	}
+	if y.history == nil {
+		y.history = &history{}
+	}
+	y.history.depth = depth
+	y.history.truncate()
+}
+
+// History return operations could be undone, the earliest first
+func (y *YQuery) History() []Operation {
+	if y.history == nil {
+		return nil
+	}
+	var operations []Operation
+	for _, entry := range y.history.undo {
+		operations = append(operations, entry.Operation)
+	}
+	return operations
+}
+
+// Undo revert the last operation, and return it
+func (y *YQuery) Undo() (Operation, error) {
+	if y.finished {
+		return Operation{}, errFinished
+	}
+	if y.history == nil || len(y.history.undo) == 0 {
+		return Operation{}, fmt.Errorf("nothing to undo")
+	}
+	h := y.history
+	entry := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, historyEntry{entry.Operation, y.RootNode, y.document})
+	y.RootNode, y.document = entry.root, entry.document
+	y.generation++
+	return entry.Operation, nil
+}
+
+// Redo apply the last undone operation again, and return it
+// Redo list is cleared when the document is modified.
+func (y *YQuery) Redo() (Operation, error) {
+	if y.finished {
+		return Operation{}, errFinished
+	}
+	if y.history == nil || len(y.history.redo) == 0 {
+		return Operation{}, fmt.Errorf("nothing to redo")
+	}
+	h := y.history
+	entry := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, historyEntry{entry.Operation, y.RootNode, y.document})
+	y.RootNode, y.document = entry.root, entry.document
+	y.generation++
+	return entry.Operation, nil
+}
+
+// modify run fn, which modifies the document, and record it as operation
+// Nothing is recorded if fn returns error.
+func (y *YQuery) modify(operation Operation, fn func() error) error {
+	if y.finished {
+		return errFinished
+	}
+	if y.history == nil {
+		if err := fn(); err != nil {
+			return err
+		}
+		y.generation++
+		return nil
+	}
+	root, document := cloneNode(y.RootNode), y.document
+	if err := fn(); err != nil {
+		return err
+	}
+	y.generation++
+	h := y.history
+	h.undo = append(h.undo, historyEntry{operation, root, document})
+	h.redo = nil
+	if h.transaction {
+		h.operations = append(h.operations, operation)
+	}
+	h.truncate()
+	return nil
+}
+
+func (h *history) truncate() {
+	if len(h.undo) > h.depth {
+		h.undo = h.undo[len(h.undo)-h.depth:]
+	}
+}
@@ -0,0 +1,94 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+func TestUndoRedo(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	yq.SetHistoryDepth(10)
+	asserts.NoError(yq.Set("a", "new title"))
+	asserts.NoError(yq.Delete("b"))
+	asserts.NoError(yq.Move("c.d", "c.newD"))
+	asserts.Error(yq.Set("f.d", "failed operation is not recorded"))
+	asserts.Equal([]yquery.Operation{
+		{Name: "Set", Paths: []string{"a"}},
+		{Name: "Delete", Paths: []string{"b"}},
+		{Name: "Move", Paths: []string{"c.d", "c.newD"}},
+	}, yq.History())
+
+	operation, err := yq.Undo()
+	asserts.NoError(err)
+	asserts.Equal("Move", operation.Name)
+	res, _ := yq.Get("f.d")
+	asserts.Equal("d in c", res)
+	_, err = yq.Undo()
+	asserts.NoError(err)
+	res, _ = yq.Get("b")
+	asserts.Equal("112", res)
+
+	operation, err = yq.Redo()
+	asserts.NoError(err)
+	asserts.Equal("Delete", operation.Name)
+	_, err = yq.Get("b")
+	asserts.Error(err)
+
+	// redo is cleared by new modification
+	asserts.NoError(yq.Set("a", "another title"))
+	_, err = yq.Redo()
+	asserts.Error(err)
+
+	_, _ = yq.Undo()
+	_, _ = yq.Undo()
+	_, _ = yq.Undo()
+	_, err = yq.Undo()
+	asserts.Error(err)
+	res, _ = yq.Get("a")
+	asserts.Equal("title", res)
+}
+
+func TestHistoryDepth(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	asserts.NoError(yq.Set("a", "not recorded"))
+	_, err := yq.Undo()
+	asserts.Error(err)
+
+	yq.SetHistoryDepth(2)
+	asserts.NoError(yq.Set("a", "1"))
+	asserts.NoError(yq.Set("a", "2"))
+	asserts.NoError(yq.Set("a", "3"))
+	asserts.Len(yq.History(), 2)
+	_, _ = yq.Undo()
+	_, _ = yq.Undo()
+	_, err = yq.Undo()
+	asserts.Error(err)
+	res, _ := yq.Get("a")
+	asserts.Equal("1", res)
+
+	yq.SetHistoryDepth(0)
+	asserts.Nil(yq.History())
+}
+
+func TestUndoTransaction(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	yq.SetHistoryDepth(10)
+	err := yq.Transaction(func(tx *yquery.Tx) error {
+		_ = tx.Set("a", "new title")
+		return tx.Delete("b")
+	})
+	asserts.NoError(err)
+	asserts.Equal([]yquery.Operation{{Name: "Transaction", Paths: []string{"a", "b"}}}, yq.History())
+	_, err = yq.Undo()
+	asserts.NoError(err)
+	res, _ := yq.Get("a")
+	asserts.Equal("title", res)
+	res, _ = yq.Get("b")
+	asserts.Equal("112", res)
+}
@@ -0,0 +1,168 @@
+package yquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultJSONIndent is the indentation width of pretty JSON
+const defaultJSONIndent = 2
+
+// jsonNumber matches numbers written in JSON syntax
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// UnmarshalJSON parse JSON data into a document
+// Keys keep their order, and numbers keep their text, e.g. 12345678901234567890 and 1.10 are not rounded.
+// Duplicated keys and numbers out of the range of float are errors.
+// The document is written as block style yaml by Marshal, and as JSON by MarshalJSON.
+func UnmarshalJSON(in []byte) (*YQuery, error) {
+	node, err := jsonValueNode(in)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json: %s", err)
+	}
+	return &YQuery{
+		RootNode: node,
+		document: yaml.Node{Kind: yaml.DocumentNode},
+	}, nil
+}
+
+// JSONOptions is the parameter for MarshalJSONWith
+type JSONOptions struct {
+	// Pretty could be set to true to write each item in its own line with indentation
+	Pretty bool
+	// Indent is the number of spaces of each indentation level of pretty JSON, default is 2
+	Indent int
+}
+
+// MarshalJSON marshal the document as compact JSON, see MarshalJSONWith
+// It implements json.Marshaler, so YQuery could be a field of struct encoded by encoding/json.
+func (y *YQuery) MarshalJSON() ([]byte, error) {
+	return y.MarshalJSONWith(JSONOptions{})
+}
+
+// MarshalJSONWith marshal the document as JSON with options
+//     out, err := yq.MarshalJSONWith(yquery.JSONOptions{Pretty: true})
+//
+// Anchor references and merge keys are resolved, comments are dropped. Numbers keep their text if it is valid JSON.
+// It returns error for yaml features having no JSON equivalent: keys which are not strings, binary data,
+// timestamps, infinity, NaN, numbers out of the range of float and custom tags. An empty document is written as null.
+func (y *YQuery) MarshalJSONWith(options JSONOptions) ([]byte, error) {
+	if options.Indent < 0 {
+		return nil, fmt.Errorf("indent could not be negative, got %d", options.Indent)
+	}
+	if y.RootNode == nil {
+		return []byte("null"), nil
+	}
+	node, err := explodeNode(y.RootNode, nil)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	if err := writeJSON(&buffer, "", node); err != nil {
+		return nil, err
+	}
+	if !options.Pretty {
+		return buffer.Bytes(), nil
+	}
+	if options.Indent == 0 {
+		options.Indent = defaultJSONIndent
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, buffer.Bytes(), "", strings.Repeat(" ", options.Indent)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// writeJSON write node as compact JSON to buffer, path is the path of node used in error message
+func writeJSON(buffer *bytes.Buffer, path string, node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode && node.Tag != "" && node.ShortTag() != implicitTag(node) {
+		return fmt.Errorf("item '%s' has tag %s, which has no JSON equivalent", path, node.Tag)
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		buffer.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			keyPath := joinPath(path, key.Value)
+			if key.Kind != yaml.ScalarNode || key.ShortTag() != strTag {
+				return fmt.Errorf("key '%s' is %s, JSON only accepts string keys", keyPath, key.ShortTag())
+			}
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeJSONString(buffer, key.Value)
+			buffer.WriteByte(':')
+			if err := writeJSON(buffer, keyPath, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte('}')
+		return nil
+	case yaml.SequenceNode:
+		buffer.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := writeJSON(buffer, fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+		return nil
+	}
+	value, err := jsonScalar(node)
+	if err != nil {
+		return fmt.Errorf("item '%s' %s", path, err)
+	}
+	buffer.WriteString(value)
+	return nil
+}
+
+// jsonScalar return the JSON text of a scalar node
+func jsonScalar(node *yaml.Node) (string, error) {
+	switch tag := node.ShortTag(); tag {
+	case strTag:
+		if node.Style == 0 && jsonNumber.MatchString(node.Value) && implicitTag(&yaml.Node{Kind: yaml.ScalarNode, Value: node.Value}) == strTag {
+			// a plain number resolves to string when it is out of the range of float
+			return "", fmt.Errorf("is %s, which is out of the range of JSON number", node.Value)
+		}
+		var buffer bytes.Buffer
+		writeJSONString(&buffer, node.Value)
+		return buffer.String(), nil
+	case nullTag:
+		return "null", nil
+	case boolTag, intTag, floatTag:
+		if tag != boolTag && jsonNumber.MatchString(node.Value) {
+			return node.Value, nil
+		}
+		// other forms, e.g. "0x1F", "+1", "1_000" or "True"
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return "", err
+		}
+		out, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("is %s, which has no JSON equivalent", node.Value)
+		}
+		return string(out), nil
+	default:
+		return "", fmt.Errorf("has tag %s, which has no JSON equivalent, quote it to write it as string", tag)
+	}
+}
+
+// writeJSONString write s as JSON string, without escaping html characters
+func writeJSONString(buffer *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	// encoding string never fails
+	_ = encoder.Encode(s)
+	// remove the newline written by Encode
+	buffer.Truncate(buffer.Len() - 1)
+}
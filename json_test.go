@@ -0,0 +1,136 @@
+package yquery_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=json
+var jsonData = `{
+  "name": "api",
+  "id": 12345678901234567890,
+  "price": 1.10,
+  "enabled": true,
+  "version": "1.0",
+  "tags": [
+    "a",
+    "<b>"
+  ],
+  "empty": {},
+  "nothing": null
+}`
+
+func TestUnmarshalJSON(t *testing.T) {
+	asserts := assert.New(t)
+	yq, err := yquery.UnmarshalJSON([]byte(jsonData))
+	asserts.NoError(err)
+	res, err := yq.Get("id")
+	asserts.NoError(err)
+	asserts.Equal("12345678901234567890", res)
+	res, _ = yq.Get("tags[1]")
+	asserts.Equal("<b>", res)
+
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal(`name: api
+id: 12345678901234567890
+price: 1.10
+enabled: true
+version: "1.0"
+tags:
+    - a
+    - <b>
+empty: {}
+nothing: null
+`, string(out))
+
+	out, err = yq.MarshalJSONWith(yquery.JSONOptions{Pretty: true})
+	asserts.NoError(err)
+	asserts.Equal(jsonData, string(out))
+
+	_, err = yquery.UnmarshalJSON([]byte(`{"a": 1,}`))
+	asserts.Error(err)
+}
+
+func TestUnmarshalJSONStrict(t *testing.T) {
+	asserts := assert.New(t)
+	yq, err := yquery.UnmarshalJSON([]byte(`{"a":"x\/y","<<":{"b":1},"c":"1.0e+400"}`))
+	asserts.NoError(err)
+	res, _ := yq.Get("a")
+	asserts.Equal("x/y", res)
+	// "<<" is a string key, not a merge key
+	_, err = yq.Get("b")
+	asserts.Error(err)
+	out, _ := yq.Marshal()
+	asserts.Equal("a: x/y\n\"<<\":\n    b: 1\nc: \"1.0e+400\"\n", string(out))
+	reloaded, err := yquery.Unmarshal(out)
+	asserts.NoError(err)
+	out, err = reloaded.MarshalJSON()
+	asserts.NoError(err)
+	asserts.Equal(`{"a":"x/y","<<":{"b":1},"c":"1.0e+400"}`, string(out))
+
+	for _, data := range []string{`{"a":1,"a":2}`, `{"a":{"b":1,"b":1}}`, `[1.0e+400]`, `{"a":1} {}`, ``} {
+		_, err = yquery.UnmarshalJSON([]byte(data))
+		asserts.Error(err, data)
+	}
+}
+
+// language=yaml
+var jsonYamlData = `
+base: &base
+  host: localhost
+  port: 0x1F
+service:
+  <<: *base
+  port: 8080
+  ratio: .5
+  list: [*base]
+`
+
+func TestMarshalJSON(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(jsonYamlData))
+	out, err := yq.MarshalJSON()
+	asserts.NoError(err)
+	asserts.Equal(`{"base":{"host":"localhost","port":31},"service":{"host":"localhost","port":8080,"ratio":0.5,"list":[{"host":"localhost","port":31}]}}`, string(out))
+
+	out, err = json.Marshal(struct {
+		Data *yquery.YQuery `json:"data"`
+	}{yq})
+	asserts.NoError(err)
+	asserts.Contains(string(out), `{"data":{"base":`)
+
+	out, err = yq.MarshalJSONWith(yquery.JSONOptions{Pretty: true, Indent: 4})
+	asserts.NoError(err)
+	asserts.Contains(string(out), "{\n    \"base\": {\n        \"host\"")
+
+	empty, _ := yquery.Unmarshal(nil)
+	out, err = empty.MarshalJSON()
+	asserts.NoError(err)
+	asserts.Equal("null", string(out))
+}
+
+func TestMarshalJSONError(t *testing.T) {
+	asserts := assert.New(t)
+	testCases := []casePair{
+		{"a:\n  1: one\n", "key 'a.1' is !!int, JSON only accepts string keys"},
+		{"a:\n  - ? [x]\n    : y\n", "key 'a[0].' is !!seq, JSON only accepts string keys"},
+		{"a: !!binary aGVsbG8=\n", "item 'a' has tag !!binary, which has no JSON equivalent, quote it to write it as string"},
+		{"a: 2001-12-14\n", "item 'a' has tag !!timestamp, which has no JSON equivalent, quote it to write it as string"},
+		{"a: .inf\n", "item 'a' is .inf, which has no JSON equivalent"},
+		{"a: 1.0e+400\n", "item 'a' is 1.0e+400, which is out of the range of JSON number"},
+		{"a: !custom {b: 1}\n", "item 'a' has tag !custom, which has no JSON equivalent"},
+	}
+	for _, c := range testCases {
+		yq, err := yquery.Unmarshal([]byte(c.Parser))
+		asserts.NoError(err)
+		_, err = yq.MarshalJSON()
+		if asserts.Error(err) {
+			asserts.Equal(c.Value, err.Error())
+		}
+	}
+}
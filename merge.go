@@ -0,0 +1,77 @@
+package yquery
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// functions handle merge key
+
+// mergeSources return the mapping nodes merged into node, ordered from the highest precedence to the lowest
+// A merge item could be a mapping, or a sequence of mappings, e.g.
+//     a:
+//       <<: [*base, *override]
+//
+// In a sequence, the former mapping override the latter one, as defined in yaml merge key spec.
+// When there are more than one merge key in one node, the latter key override the former one.
+//     a:
+//       <<: *base
+//       <<: *override
+//
+// Keys defined directly in node are not included, they always override merged keys.
+func mergeSources(node *yaml.Node) []*yaml.Node {
+	var sources []*yaml.Node
+	for index := len(node.Content) - 2; index >= 0; index -= 2 {
+		if node.Content[index].Tag != mergeTag {
+			continue
+		}
+		value := resolveAlias(node.Content[index+1])
+		switch value.Kind {
+		case yaml.MappingNode:
+			sources = append(sources, value)
+		case yaml.SequenceNode:
+			for _, content := range value.Content {
+				if content = resolveAlias(content); content.Kind == yaml.MappingNode {
+					sources = append(sources, content)
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// findInMerge search key in the merge items of a mapping node
+// It only search the items comes from "<<", keys defined directly in node are ignored.
+func findInMerge(node *yaml.Node, key string) *yaml.Node {
+	mapping, index := findEntryInMerge(node, key)
+	if mapping == nil {
+		return nil
+	}
+	return mapping.Content[index]
+}
+
+// findEntry search key in mapping node, including its merge items
+// It returns the mapping node defines the key, and the index of value in its content.
+func findEntry(node *yaml.Node, key string) (*yaml.Node, int) {
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		if node.Content[index].Tag != mergeTag && node.Content[index].Value == key {
+			return node, index + 1
+		}
+	}
+	return findEntryInMerge(node, key)
+}
+
+// findEntryInMerge is similar to findEntry, but only search the merge items
+func findEntryInMerge(node *yaml.Node, key string) (*yaml.Node, int) {
+	for _, source := range mergeSources(node) {
+		if mapping, index := findEntry(source, key); mapping != nil {
+			return mapping, index
+		}
+	}
+	return nil, 0
+}
+
+// materializeMergeItem copy the value found in merge item, so that it could be override in node directly
+// Alias is replaced by the copy of its target, because value could not be modified through anchor reference.
+func materializeMergeItem(value *yaml.Node) *yaml.Node {
+	return copyNode(resolveAlias(value))
+}
@@ -0,0 +1,325 @@
+package yquery
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Conflict is an item changed differently in both sides of a three-way merge
+type Conflict struct {
+	// Path is the parser string of the item, which could be used in Get
+	Path string
+	// Base, Ours and Theirs are the values in each document, nil if the item does not exist in it
+	Base   *yaml.Node
+	Ours   *yaml.Node
+	Theirs *yaml.Node
+}
+
+// Conflicts is a list of Conflict, in the order of the merged document
+type Conflicts []Conflict
+
+// Merge3Config is the optional parameter for Merge3
+type Merge3Config struct {
+	// Delimiter is the custom delimiter used in Path of conflicts
+	Delimiter string
+	// Markers could be set to true to write conflict markers as comments above conflicted items
+	//     # <<<<<<< ours
+	//     # "value of ours"
+	//     # ||||||| base
+	//     # "value of base"
+	//     # =======
+	//     # "value of theirs"
+	//     # >>>>>>> theirs
+	//     key: value of ours
+	Markers bool
+}
+
+// Merge3 merge changes from base to ours and from base to theirs, return the merged document and conflicts
+// Documents are merged by node: mapping items by key, sequence items by index.
+// Items appended to the end of a sequence in both sides are all kept, ours first.
+// An item changed in only one side takes that change, an item changed in both sides is a conflict unless the changes are the same.
+// The merged document keeps the value of ours for conflicted items, or theirs if ours deleted it.
+// Anchors, references and merge keys are kept. Only a reference or a mapping with merge keys changed in both sides is expanded
+// to merge its items. References are bound to the anchor of the same name in the merged document,
+// or replaced by a copy of their data if the anchor is removed.
+// Comments, styles, document comments and the format detected by Load of ours are kept.
+func Merge3(base, ours, theirs *YQuery, config ...Merge3Config) (*YQuery, Conflicts, error) {
+	if len(config) > 1 {
+		return nil, nil, fmt.Errorf("merge3 could only get 0 or 1 config, got %d", len(config))
+	}
+	c := Merge3Config{}
+	if len(config) == 1 {
+		c = config[0]
+	}
+	delimiter, err := getDelimiter([]Config{{Delimiter: c.Delimiter}})
+	if err != nil {
+		return nil, nil, err
+	}
+	// nodes are merged in place, and nodes of theirs could be moved into the merged document
+	var nodes [3]*yaml.Node
+	for i, y := range []*YQuery{base, ours, theirs} {
+		nodes[i] = cloneNode(y.RootNode)
+	}
+	m := merger3{delimiter: delimiter, markers: c.Markers}
+	root := m.merge(nil, nil, nodes[0], nodes[1], nodes[2])
+	if err := bindAliases(root); err != nil {
+		return nil, nil, err
+	}
+	return &YQuery{RootNode: root, format: ours.format, document: ours.document, directives: ours.directives, documentStart: ours.documentStart}, m.conflicts, nil
+}
+
+type merger3 struct {
+	delimiter string
+	markers   bool
+	conflicts Conflicts
+}
+
+// merge return the merged node of base, ours and theirs, each of them could be nil if the item does not exist
+// key is the key node of the mapping item, nil for sequence item and root, conflict markers are put on it if exists.
+func (m *merger3) merge(slices []string, key *yaml.Node, base, ours, theirs *yaml.Node) *yaml.Node {
+	switch {
+	case sameData(ours, theirs), sameData(base, theirs):
+		return ours
+	case sameData(base, ours):
+		return theirs
+	}
+	if ours != nil && theirs != nil && resolveAlias(ours).Kind == resolveAlias(theirs).Kind {
+		if ours.Alias != nil {
+			// the reference is changed in both sides, merge on a copy of its data
+			ours = copyNode(resolveAlias(ours))
+		}
+		theirs = resolveAlias(theirs)
+		origin := resolveAlias(base)
+		if origin == nil || origin.Kind != ours.Kind {
+			// merge as if the item is empty in base
+			origin = &yaml.Node{Kind: ours.Kind}
+		}
+		switch ours.Kind {
+		case yaml.MappingNode:
+			if !sameMergeKeys(origin, ours, theirs) {
+				ours.Content = flattenMerge(ours).Content
+				origin, theirs = flattenMerge(origin), flattenMerge(theirs)
+			}
+			m.mergeMapping(slices, origin, ours, theirs)
+			return ours
+		case yaml.SequenceNode:
+			if m.mergeSequence(slices, origin, ours, theirs) {
+				return ours
+			}
+		}
+	}
+	merged := ours
+	if merged == nil {
+		merged = theirs
+	}
+	m.conflict(slices, key, merged, Conflict{Base: base, Ours: ours, Theirs: theirs})
+	return merged
+}
+
+func (m *merger3) mergeMapping(slices []string, base, ours, theirs *yaml.Node) {
+	content := make([]*yaml.Node, 0, len(ours.Content))
+	for i := 0; i+1 < len(ours.Content); i += 2 {
+		key := ours.Content[i]
+		merged := m.merge(append(slices, key.Value), key, mappingValue(base, key.Value), ours.Content[i+1], mappingValue(theirs, key.Value))
+		if merged != nil {
+			content = append(content, key, merged)
+		}
+	}
+	for i := 0; i+1 < len(theirs.Content); i += 2 {
+		key := theirs.Content[i]
+		if mappingValue(ours, key.Value) != nil {
+			continue
+		}
+		merged := m.merge(append(slices, key.Value), key, mappingValue(base, key.Value), nil, theirs.Content[i+1])
+		if merged != nil {
+			content = append(content, key, merged)
+		}
+	}
+	ours.Content = content
+}
+
+// mergeSequence merge sequences item by item, return false if they could not be merged
+// Sequences are merged when they have the same length as base, or both only append items to base.
+func (m *merger3) mergeSequence(slices []string, base, ours, theirs *yaml.Node) bool {
+	if len(ours.Content) == len(base.Content) && len(theirs.Content) == len(base.Content) {
+		for i := range ours.Content {
+			ours.Content[i] = m.merge(append(slices, fmt.Sprintf("[%d]", i)), nil, base.Content[i], ours.Content[i], theirs.Content[i])
+		}
+		return true
+	}
+	if !hasPrefix(ours, base) || !hasPrefix(theirs, base) {
+		return false
+	}
+	appended := ours.Content[len(base.Content):]
+	for _, item := range theirs.Content[len(base.Content):] {
+		exists := false
+		for _, existing := range appended {
+			exists = exists || sameData(existing, item)
+		}
+		if !exists {
+			ours.Content = append(ours.Content, item)
+		}
+	}
+	return true
+}
+
+// conflict record a conflict, and write markers to key, or to node if key is nil
+func (m *merger3) conflict(slices []string, key *yaml.Node, node *yaml.Node, conflict Conflict) {
+	conflict.Path = strings.Replace(strings.Join(slices, m.delimiter), m.delimiter+"[", "[", -1)
+	m.conflicts = append(m.conflicts, conflict)
+	if !m.markers {
+		return
+	}
+	if key != nil {
+		node = key
+	}
+	markers := []string{
+		"<<<<<<< ours", conflictValue(conflict.Ours),
+		"||||||| base", conflictValue(conflict.Base),
+		"=======", conflictValue(conflict.Theirs),
+		">>>>>>> theirs",
+	}
+	if node.HeadComment != "" {
+		markers = append([]string{parseComment(node.HeadComment)}, markers...)
+	}
+	node.HeadComment = formatComment(strings.Join(markers, "\n"))
+}
+
+// conflictValue return value of node in one line for conflict markers
+func conflictValue(node *yaml.Node) string {
+	if node == nil {
+		return "(none)"
+	}
+	return reportValue(node)
+}
+
+// sameData compare nodes by their data, nil is only the same as nil
+func sameData(a *yaml.Node, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return sameNode(a, b)
+}
+
+// hasPrefix return true if sequence starts with all items of prefix
+func hasPrefix(sequence *yaml.Node, prefix *yaml.Node) bool {
+	if len(sequence.Content) < len(prefix.Content) {
+		return false
+	}
+	for i, item := range prefix.Content {
+		if !sameData(sequence.Content[i], item) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameMergeKeys return true if mappings have the same merge keys, they are merged as other keys then
+func sameMergeKeys(mappings ...*yaml.Node) bool {
+	var first []*yaml.Node
+	for i, mapping := range mappings {
+		var values []*yaml.Node
+		for index := 0; index+1 < len(mapping.Content); index += 2 {
+			if mapping.Content[index].Tag == mergeTag {
+				values = append(values, mapping.Content[index+1])
+			}
+		}
+		if i == 0 {
+			first = values
+			continue
+		}
+		if len(values) != len(first) {
+			return false
+		}
+		for j := range values {
+			if !sameData(values[j], first[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// flattenMerge return a mapping with copies of items inherited from merge keys in place of the merge keys
+// Only the mapping itself is flattened, anchors and references in its items are kept.
+func flattenMerge(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.MappingNode || len(mergeSources(node)) == 0 {
+		return node
+	}
+	result := *node
+	result.Content = nil
+	added := map[string]bool{}
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		if node.Content[index].Tag != mergeTag {
+			added[node.Content[index].Value] = true
+		}
+	}
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		if node.Content[index].Tag != mergeTag {
+			result.Content = append(result.Content, node.Content[index], node.Content[index+1])
+			continue
+		}
+		// inherited items are placed where the merge key is, in the order they are defined
+		source := resolveAlias(node.Content[index+1])
+		mappings := []*yaml.Node{source}
+		if source.Kind == yaml.SequenceNode {
+			mappings = source.Content
+		}
+		for _, mapping := range mappings {
+			mapping = flattenMerge(resolveAlias(mapping))
+			for i := 0; i+1 < len(mapping.Content); i += 2 {
+				key := mapping.Content[i]
+				if key.Tag == mergeTag || added[key.Value] {
+					continue
+				}
+				added[key.Value] = true
+				result.Content = append(result.Content, copyNode(key), copyNode(findInMerge(node, key.Value)))
+			}
+		}
+	}
+	return &result
+}
+
+// bindAliases make anchor references in root point to anchors in root
+// References to nodes not in root, e.g. anchors replaced by theirs, are bound to the anchor with the same name,
+// or replaced by a copy of their data. Anchors defined more than once are renamed.
+func bindAliases(root *yaml.Node) error {
+	inRoot := map[*yaml.Node]bool{}
+	anchors := map[string]*yaml.Node{}
+	walkNode(root, func(node *yaml.Node) {
+		inRoot[node] = true
+		if node.Anchor == "" {
+			return
+		}
+		name := node.Anchor
+		for i := 2; anchors[name] != nil && anchors[name] != node; i++ {
+			name = fmt.Sprintf("%s_%d", node.Anchor, i)
+		}
+		node.Anchor = name
+		anchors[name] = node
+	})
+	var err error
+	walkNode(root, func(node *yaml.Node) {
+		for i, content := range node.Content {
+			if content.Alias == nil || err != nil {
+				continue
+			}
+			target := content.Alias
+			if !inRoot[target] {
+				if target = anchors[content.Value]; target == nil {
+					node.Content[i], err = explodeNode(content.Alias, nil)
+					continue
+				}
+			}
+			content.Alias = target
+			content.Value = target.Anchor
+		}
+	})
+	if err != nil {
+		return err
+	}
+	orderAnchors(root)
+	return nil
+}
@@ -0,0 +1,250 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var merge3Base = `a: 1
+b:
+  c: 2
+  d: 3
+list:
+  - x
+  - y
+items:
+  - name: first
+    value: 1
+removed: true
+conflict: base
+`
+
+func TestMerge3(t *testing.T) {
+	asserts := assert.New(t)
+	base, _ := yquery.Unmarshal([]byte(merge3Base))
+	ours, _ := yquery.Unmarshal([]byte(`# comment of ours
+a: 10
+b:
+  c: 2
+  d: 3
+  e: ours
+list:
+  - x
+  - y
+  - ours
+items:
+  - name: first
+    value: 1
+conflict: ours
+`))
+	theirs, _ := yquery.Unmarshal([]byte(`a: 1
+b:
+  c: 20
+  d: 3
+  f: theirs
+list:
+  - x
+  - y
+  - theirs
+items:
+  - name: first
+    value: 2
+removed: true
+conflict: theirs
+new: theirs
+`))
+	merged, conflicts, err := yquery.Merge3(base, ours, theirs)
+	asserts.NoError(err)
+	testCases := []casePair{
+		{"a", "10"},
+		{"b.c", "20"},
+		{"b.e", "ours"},
+		{"b.f", "theirs"},
+		{"list[2]", "ours"},
+		{"list[3]", "theirs"},
+		{"items[0].value", "2"},
+		{"conflict", "ours"},
+		{"new", "theirs"},
+	}
+	for _, c := range testCases {
+		v, err := merged.Get(c.Parser)
+		asserts.NoError(err, c.Parser)
+		asserts.Equal(c.Value, v, c.Parser)
+	}
+	_, err = merged.Get("removed")
+	asserts.Error(err)
+	comment, _ := merged.GetComment("a")
+	asserts.Equal("comment of ours", comment.KeyHead)
+
+	if asserts.Len(conflicts, 1) {
+		asserts.Equal("conflict", conflicts[0].Path)
+		asserts.Equal("base", conflicts[0].Base.Value)
+		asserts.Equal("ours", conflicts[0].Ours.Value)
+		asserts.Equal("theirs", conflicts[0].Theirs.Value)
+	}
+	// inputs are not modified
+	v, _ := ours.Get("b.c")
+	asserts.Equal("2", v)
+}
+
+func TestMerge3Conflicts(t *testing.T) {
+	asserts := assert.New(t)
+	base, _ := yquery.Unmarshal([]byte(merge3Base))
+	ours, _ := yquery.Unmarshal([]byte(`a: 1
+b:
+  c: 2
+list:
+  - z
+items:
+  - name: first
+    value: 1
+removed: false
+conflict: base
+`))
+	theirs, _ := yquery.Unmarshal([]byte(`a: 1
+b:
+  c: 2
+  d: 30
+list:
+  - x
+items:
+  - name: first
+    value: 1
+conflict: base
+`))
+	merged, conflicts, err := yquery.Merge3(base, ours, theirs, yquery.Merge3Config{Markers: true})
+	asserts.NoError(err)
+	var paths []string
+	for _, c := range conflicts {
+		paths = append(paths, c.Path)
+	}
+	asserts.Equal([]string{"b.d", "list", "removed"}, paths)
+	v, _ := merged.Get("b.d")
+	asserts.Equal("30", v)
+	v, _ = merged.Get("removed")
+	asserts.Equal("false", v)
+	comment, _ := merged.GetComment("removed")
+	asserts.Equal("<<<<<<< ours\n\"false\"\n||||||| base\n\"true\"\n=======\n(none)\n>>>>>>> theirs", comment.KeyHead)
+	out, err := merged.Marshal()
+	asserts.NoError(err)
+	_, err = yquery.Unmarshal(out)
+	asserts.NoError(err)
+}
+
+func TestMerge3Anchors(t *testing.T) {
+	asserts := assert.New(t)
+	base, _ := yquery.Unmarshal([]byte(`defaults: &defaults
+  image: app:1
+  replicas: 1
+web:
+  <<: *defaults
+  port: 80
+worker: *defaults
+`))
+	ours, _ := yquery.Unmarshal([]byte(`defaults: &defaults
+  image: app:1
+  replicas: 1
+web:
+  <<: *defaults
+  port: 8080
+worker: *defaults
+`))
+	theirs, _ := yquery.Unmarshal([]byte(`defaults: &defaults
+  image: app:2
+  replicas: 1
+web:
+  <<: *defaults
+  port: 80
+worker: *defaults
+api:
+  <<: *defaults
+  port: 81
+`))
+	merged, conflicts, err := yquery.Merge3(base, ours, theirs)
+	asserts.NoError(err)
+	asserts.Empty(conflicts)
+	out, _ := merged.Marshal()
+	asserts.Equal(`defaults: &defaults
+    image: app:2
+    replicas: 1
+web:
+    <<: *defaults
+    port: 8080
+worker: *defaults
+api:
+    <<: *defaults
+    port: 81
+`, string(out))
+
+	// the anchor is removed in theirs, and a new reference is added in ours
+	// web of ours has the same data as theirs, its reference is replaced by a copy of the removed anchor
+	theirs, _ = yquery.Unmarshal([]byte(`web:
+  image: app:1
+  replicas: 1
+  port: 80
+worker:
+  image: app:1
+  replicas: 2
+`))
+	ours, _ = yquery.Unmarshal([]byte(`defaults: &defaults
+  image: app:1
+  replicas: 1
+web:
+  <<: *defaults
+  port: 80
+worker: *defaults
+extra: *defaults
+`))
+	merged, conflicts, err = yquery.Merge3(base, ours, theirs)
+	asserts.NoError(err)
+	asserts.Empty(conflicts)
+	out, _ = merged.Marshal()
+	asserts.Equal(`web:
+    <<:
+        image: app:1
+        replicas: 1
+    port: 80
+worker:
+    image: app:1
+    replicas: 2
+extra:
+    image: app:1
+    replicas: 1
+`, string(out))
+
+	// merge key is removed in theirs, the items inherited by ours are copied to merge with theirs
+	theirs, _ = yquery.Unmarshal([]byte(`defaults: &defaults
+  image: app:1
+  replicas: 1
+web:
+  image: app:3
+  replicas: 1
+  port: 80
+worker: *defaults
+`))
+	ours, _ = yquery.Unmarshal([]byte(`defaults: &defaults
+  image: app:1
+  replicas: 1
+web:
+  <<: *defaults
+  port: 8080
+worker: *defaults
+`))
+	merged, conflicts, err = yquery.Merge3(base, ours, theirs)
+	asserts.NoError(err)
+	asserts.Empty(conflicts)
+	out, _ = merged.Marshal()
+	asserts.Equal(`defaults: &defaults
+    image: app:1
+    replicas: 1
+web:
+    image: app:3
+    replicas: 1
+    port: 8080
+worker: *defaults
+`, string(out))
+}
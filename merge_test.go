@@ -0,0 +1,122 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var mergeData = `
+base: &base
+  a:
+    b: 333
+    c: testValue
+  d: otherValue
+  l:
+    - l1
+    - l2
+o:
+  <<: *base
+`
+
+func TestSetInMergeShouldError(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(mergeData))
+	err := yq.Set("o.a.b", "444")
+	asserts.Error(err)
+	res, _ := yq.Get("o.a.b")
+	asserts.Equal("333", res)
+}
+
+func TestSetInMergeForce(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(mergeData))
+	err := yq.Set("o.a.b", "444", yquery.Config{ForceInMerge: true})
+	asserts.NoError(err)
+	res, _ := yq.Get("o.a.b")
+	asserts.Equal("444", res)
+	_, err = yq.Get("o.a.c")
+	asserts.Error(err)
+}
+
+func TestSetInMergeMaterialize(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(mergeData))
+	testCases := []casePair{
+		{"o.a.b", "444"},
+		{"o.l[1]", "new l2"},
+		{"o.a.e", "new item"},
+	}
+	for _, c := range testCases {
+		err := yq.Set(c.Parser, c.Value, yquery.Config{MaterializeMerge: true})
+		asserts.NoError(err)
+		res, _ := yq.Get(c.Parser)
+		asserts.Equal(c.Value, res)
+	}
+	inherited := []casePair{
+		{"o.a.c", "testValue"},
+		{"o.d", "otherValue"},
+		{"o.l[0]", "l1"},
+		{"base.a.b", "333"},
+		{"base.l[1]", "l2"},
+	}
+	for _, c := range inherited {
+		res, err := yq.Get(c.Parser)
+		asserts.NoError(err)
+		asserts.Equal(c.Value, res)
+	}
+	_, err := yq.Get("base.a.e")
+	asserts.Error(err)
+}
+
+// language=yaml
+var sequenceMergeData = `
+base1: &base1
+  a: a in base1
+  b: b in base1
+base2: &base2
+  a: a in base2
+  c: c in base2
+base3: &base3
+  d: d in base3
+base4: &base4
+  e: e in base4
+o:
+  <<: [*base1, *base2]
+  b: b in o
+p:
+  <<: *base1
+  <<: *base2
+  <<: *base3
+  <<: *base4
+  <<: {f: f in flow}
+`
+
+func TestGetSequenceMerge(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(sequenceMergeData))
+	testCases := []casePair{
+		{"o.a", "a in base1"},
+		{"o.b", "b in o"},
+		{"o.c", "c in base2"},
+		{"p.a", "a in base2"},
+		{"p.b", "b in base1"},
+		{"p.d", "d in base3"},
+		{"p.e", "e in base4"},
+		{"p.f", "f in flow"},
+	}
+	for _, c := range testCases {
+		res, err := yq.Get(c.Parser)
+		asserts.NoError(err)
+		asserts.Equal(c.Value, res, c.Parser)
+	}
+	asserts.NoError(yq.Explode())
+	for _, c := range testCases {
+		res, err := yq.Get(c.Parser)
+		asserts.NoError(err)
+		asserts.Equal(c.Value, res, c.Parser)
+	}
+}
@@ -0,0 +1,484 @@
+package yquery
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalMinimal marshal the document by changing only lines of modified items in the source
+// Source is the data given to Unmarshal or Load, or the data last written by Save.
+// Lines of items not modified, including blank lines, spacing and comments, are kept byte by byte.
+// A modified scalar is replaced in its line, other modified items are written again in the format detected from source,
+// new items are inserted after the previous item, and lines of deleted items are removed.
+// If the root node itself is replaced, e.g. changed from mapping to sequence,
+// or the document comment is modified, the whole document is written again.
+func (y *YQuery) MarshalMinimal() ([]byte, error) {
+	if y.source == nil {
+		return nil, fmt.Errorf("the document has no source, it is not unmarshalled or loaded")
+	}
+	_, document, err := unmarshalDocument(y.source)
+	f := defaultFormat
+	switch {
+	case y.format != nil:
+		f = *y.format
+	case err == nil && len(document.Content) > 0:
+		// unmarshalled from bytes, items written again follow the source as well
+		f = detectFormat(y.source, document.Content[0])
+	}
+	if err != nil || len(document.Content) == 0 || y.RootNode == nil ||
+		document.HeadComment != y.document.HeadComment || document.FootComment != y.document.FootComment {
+		return y.encode(f)
+	}
+	p := newPatcher(y.source, f)
+	root := document.Content[0]
+	if !p.patch(root, y.RootNode, p.documentEnd(root)) {
+		return y.encode(f)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.result(), nil
+}
+
+// lineEdit replace lines from start to end (1-based, end exclusive) with lines, it is an insertion if start equals end
+type lineEdit struct {
+	start int
+	end   int
+	lines []string
+}
+
+type patcher struct {
+	// lines are lines of source without line endings
+	lines []string
+	// format is the format of the whole document
+	format format
+	edits  []lineEdit
+	// err is the first error when writing items
+	err error
+}
+
+func newPatcher(source []byte, f format) *patcher {
+	text := strings.Replace(string(source), "\r\n", "\n", -1)
+	return &patcher{
+		lines:  strings.Split(strings.TrimSuffix(text, "\n"), "\n"),
+		format: f,
+	}
+}
+
+// result apply edits to lines, and join them with line endings of format
+func (p *patcher) result() []byte {
+	sort.SliceStable(p.edits, func(i, j int) bool {
+		return p.edits[i].start < p.edits[j].start
+	})
+	var lines []string
+	line := 1
+	for _, edit := range p.edits {
+		lines = append(lines, p.lines[line-1:edit.start-1]...)
+		lines = append(lines, edit.lines...)
+		line = edit.end
+	}
+	lines = append(lines, p.lines[line-1:]...)
+	out := strings.Join(lines, "\n")
+	if !p.format.noTrailingNewline {
+		out += "\n"
+	}
+	if p.format.crlf {
+		out = strings.Replace(out, "\n", "\r\n", -1)
+	}
+	return []byte(out)
+}
+
+// documentEnd return the last line of the first document
+func (p *patcher) documentEnd(root *yaml.Node) int {
+	for line := root.Line + 1; line <= len(p.lines); line++ {
+		if strings.HasPrefix(p.lines[line-1], "---") || strings.HasPrefix(p.lines[line-1], "...") {
+			return line - 1
+		}
+	}
+	return len(p.lines)
+}
+
+// patch add edits to turn origin (parsed from source) into node, end is the last line of origin
+// It returns false if origin could not be patched, and the item holding it should be written again.
+func (p *patcher) patch(origin *yaml.Node, node *yaml.Node, end int) bool {
+	if sameTree(origin, node) {
+		return true
+	}
+	if !sameShell(origin, node) || origin.Style&yaml.FlowStyle != 0 || len(origin.Content) == 0 {
+		return false
+	}
+	switch origin.Kind {
+	case yaml.MappingNode:
+		return p.patchMapping(origin, node, end)
+	case yaml.SequenceNode:
+		return p.patchSequence(origin, node, end)
+	}
+	return false
+}
+
+func (p *patcher) patchMapping(origin *yaml.Node, node *yaml.Node, end int) bool {
+	// index of value in origin for each key of node, -1 for new key
+	indexes := make([]int, 0, len(node.Content)/2)
+	last := -1
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		index := -1
+		for j := 0; j+1 < len(origin.Content); j += 2 {
+			if origin.Content[j].Value == node.Content[i].Value {
+				index = j + 1
+			}
+		}
+		if index >= 0 && index < last {
+			// keys are reordered
+			return false
+		}
+		if index >= 0 {
+			last = index
+		}
+		indexes = append(indexes, index)
+	}
+	starts := make([]int, 0, len(origin.Content)/2)
+	for j := 0; j+1 < len(origin.Content); j += 2 {
+		starts = append(starts, p.itemStart(origin.Content[j].Line, origin.Content[j].HeadComment))
+	}
+	itemEnd := func(j int) int {
+		if j/2+1 < len(starts) {
+			return starts[j/2+1] - 1
+		}
+		return end
+	}
+	column := origin.Content[0].Column
+	// prefix is the text before the first key in its line, e.g. "- " of the sequence item holding the mapping
+	prefix := p.linePrefix(origin.Content[0].Line, column)
+	inline := strings.TrimSpace(prefix) != ""
+	edits := len(p.edits)
+	kept := map[int]bool{}
+	// insert is the line to insert new items, before the first item at first
+	insert := starts[0]
+	for i, index := range indexes {
+		key, value := node.Content[i*2], node.Content[i*2+1]
+		if index < 0 {
+			if inline && insert == starts[0] {
+				p.edits = p.edits[:edits]
+				return false
+			}
+			p.insert(insert, p.render(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{key, value}}, column))
+			continue
+		}
+		kept[index] = true
+		originKey, originValue := origin.Content[index-1], origin.Content[index]
+		insert = p.lastContentLine(starts[index/2], itemEnd(index)) + 1
+		if sameTree(originKey, key) && (p.splice(originValue, value) || p.patch(originValue, value, itemEnd(index))) {
+			continue
+		}
+		lines := p.render(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{key, value}}, column)
+		if inline && index == 1 && !p.keepPrefix(lines, prefix, starts[0], origin.Content[0].Line) {
+			p.edits = p.edits[:edits]
+			return false
+		}
+		p.replace(starts[index/2], insert, lines)
+	}
+	if inline && !kept[1] {
+		p.edits = p.edits[:edits]
+		return false
+	}
+	for j := 1; j < len(origin.Content); j += 2 {
+		if !kept[j] {
+			p.remove(starts[j/2], itemEnd(j), j == 1, j == len(origin.Content)-1)
+		}
+	}
+	return true
+}
+
+func (p *patcher) patchSequence(origin *yaml.Node, node *yaml.Node, end int) bool {
+	column := origin.Column
+	starts := make([]int, 0, len(origin.Content))
+	for _, item := range origin.Content {
+		line := item.Line
+		for line > origin.Line && !strings.HasPrefix(p.columnText(line, column), "-") {
+			line--
+		}
+		starts = append(starts, p.itemStart(line, item.HeadComment))
+	}
+	itemEnd := func(j int) int {
+		if j+1 < len(starts) {
+			return starts[j+1] - 1
+		}
+		return end
+	}
+	render := func(item *yaml.Node) []string {
+		return p.render(&yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{item}}, column)
+	}
+	// prefix is the text before the first item in its line, e.g. "- " of the sequence item holding the sequence
+	prefix := p.linePrefix(origin.Line, column)
+	inline := strings.TrimSpace(prefix) != ""
+	edits := len(p.edits)
+	pairs := matchItems(origin.Content, node.Content)
+	if inline && (len(pairs) == 0 || pairs[0] != 0) {
+		// the first item is inserted before, or removed
+		return false
+	}
+	insert := starts[0]
+	j := 0
+	for i, item := range node.Content {
+		index := pairs[i]
+		if index < 0 {
+			p.insert(insert, render(item))
+			continue
+		}
+		for ; j < index; j++ {
+			p.remove(starts[j], itemEnd(j), j == 0, j == len(origin.Content)-1)
+		}
+		j = index + 1
+		insert = p.lastContentLine(starts[index], itemEnd(index)) + 1
+		if p.splice(origin.Content[index], item) || p.patch(origin.Content[index], item, itemEnd(index)) {
+			continue
+		}
+		lines := render(item)
+		if inline && index == 0 && !p.keepPrefix(lines, prefix, starts[0], origin.Line) {
+			p.edits = p.edits[:edits]
+			return false
+		}
+		p.replace(starts[index], insert, lines)
+	}
+	for ; j < len(origin.Content); j++ {
+		p.remove(starts[j], itemEnd(j), j == 0, j == len(origin.Content)-1)
+	}
+	return true
+}
+
+// matchItems return the index of origin item for each item, -1 for new item
+// Identical items are matched by longest common subsequence, unmatched items between them are paired in order.
+func matchItems(origin []*yaml.Node, items []*yaml.Node) []int {
+	lengths := make([][]int, len(origin)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(items)+1)
+	}
+	for i := len(origin) - 1; i >= 0; i-- {
+		for j := len(items) - 1; j >= 0; j-- {
+			switch {
+			case sameTree(origin[i], items[j]):
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	pairs := make([]int, len(items))
+	// gap holds unmatched origin items and items since the last match
+	var originGap, gap []int
+	pairGap := func() {
+		next := 0
+		for _, j := range gap {
+			pairs[j] = -1
+			if len(gap) == len(originGap) {
+				// items are modified in place
+				pairs[j] = originGap[next]
+				next++
+				continue
+			}
+			best, bestScore := -1, 0
+			for k := next; k < len(originGap); k++ {
+				if score := similarity(origin[originGap[k]], items[j]); score > bestScore {
+					best, bestScore = k, score
+				}
+			}
+			if best >= 0 {
+				pairs[j] = originGap[best]
+				next = best + 1
+			}
+		}
+		originGap, gap = nil, nil
+	}
+	i, j := 0, 0
+	for i < len(origin) && j < len(items) {
+		switch {
+		case sameTree(origin[i], items[j]):
+			pairGap()
+			pairs[j] = i
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			originGap = append(originGap, i)
+			i++
+		default:
+			gap = append(gap, j)
+			j++
+		}
+	}
+	for ; i < len(origin); i++ {
+		originGap = append(originGap, i)
+	}
+	for ; j < len(items); j++ {
+		gap = append(gap, j)
+	}
+	pairGap()
+	return pairs
+}
+
+// similarity return the number of identical items directly in mapping a and b
+func similarity(a *yaml.Node, b *yaml.Node) int {
+	score := 0
+	if a.Kind != yaml.MappingNode || b.Kind != yaml.MappingNode {
+		return score
+	}
+	for i := 0; i+1 < len(a.Content); i += 2 {
+		if value := mappingValue(b, a.Content[i].Value); value != nil && sameTree(a.Content[i+1], value) {
+			score++
+		}
+	}
+	return score
+}
+
+// splice replace scalar origin with node in its line, return false if it is not possible
+func (p *patcher) splice(origin *yaml.Node, node *yaml.Node) bool {
+	if sameTree(origin, node) {
+		return true
+	}
+	if origin.Kind != yaml.ScalarNode || node.Kind != yaml.ScalarNode ||
+		origin.HeadComment != node.HeadComment || origin.LineComment != node.LineComment || origin.FootComment != node.FootComment {
+		return false
+	}
+	old, err := renderScalar(origin)
+	if err != nil {
+		return false
+	}
+	text, err := renderScalar(node)
+	if err != nil {
+		return false
+	}
+	line := p.lines[origin.Line-1]
+	column := origin.Column - 1
+	if strings.Contains(old+text, "\n") || !strings.HasPrefix(p.columnText(origin.Line, origin.Column), old) {
+		return false
+	}
+	p.replace(origin.Line, origin.Line+1, []string{line[:column] + text + line[column+len(old):]})
+	return true
+}
+
+// linePrefix return text of line before column (1-based)
+func (p *patcher) linePrefix(line int, column int) string {
+	text := p.lines[line-1]
+	if column-1 > len(text) {
+		return text
+	}
+	return text[:column-1]
+}
+
+// keepPrefix put prefix in place of the indentation of the first line of lines written for the first item,
+// return false if it is not possible, e.g. the item starts with its head comment above the line of prefix
+func (p *patcher) keepPrefix(lines []string, prefix string, start int, line int) bool {
+	if start != line || len(lines) == 0 || len(lines[0]) < len(prefix) || strings.TrimSpace(lines[0][:len(prefix)]) != "" {
+		return false
+	}
+	lines[0] = prefix + lines[0][len(prefix):]
+	return true
+}
+
+// columnText return text of line from column (1-based)
+func (p *patcher) columnText(line int, column int) string {
+	text := p.lines[line-1]
+	if column-1 > len(text) {
+		return ""
+	}
+	return text[column-1:]
+}
+
+// itemStart return the first line of item at line, including its head comment
+func (p *patcher) itemStart(line int, headComment string) int {
+	start := line
+	count := 0
+	if headComment != "" {
+		count = strings.Count(headComment, "\n") + 1
+	}
+	for l := line - 1; l >= 1 && count > 0; l-- {
+		text := strings.TrimSpace(p.lines[l-1])
+		if text == "" {
+			continue
+		}
+		if !strings.HasPrefix(text, "#") {
+			break
+		}
+		start = l
+		count--
+	}
+	return start
+}
+
+// lastContentLine return the last line which is not blank from start to end
+func (p *patcher) lastContentLine(start int, end int) int {
+	for end > start && strings.TrimSpace(p.lines[end-1]) == "" {
+		end--
+	}
+	return end
+}
+
+func (p *patcher) insert(line int, lines []string) {
+	p.edits = append(p.edits, lineEdit{line, line, lines})
+}
+
+func (p *patcher) replace(start int, end int, lines []string) {
+	p.edits = append(p.edits, lineEdit{start, end, lines})
+}
+
+// remove remove lines of item from start to end
+// Blank lines at the end separate the item from the next one, they are kept for the last item,
+// and for an item not separated from the previous one, so that the next one is still separated.
+func (p *patcher) remove(start int, end int, first bool, last bool) {
+	if last || !first && start > 1 && strings.TrimSpace(p.lines[start-2]) != "" {
+		end = p.lastContentLine(start, end)
+	}
+	p.edits = append(p.edits, lineEdit{start, end + 1, nil})
+}
+
+// render write the only item of container, indented to column
+func (p *patcher) render(container *yaml.Node, column int) []string {
+	f := p.format
+	f.documentStart, f.noTrailingNewline, f.crlf = false, false, false
+	out, err := encode(container, f)
+	if err != nil {
+		if p.err == nil {
+			p.err = err
+		}
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	indent := strings.Repeat(" ", column-1)
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return lines
+}
+
+// renderScalar write scalar node in one line, without comments
+func renderScalar(node *yaml.Node) (string, error) {
+	n := *node
+	n.HeadComment, n.LineComment, n.FootComment = "", "", ""
+	out, err := yaml.Marshal(&n)
+	return string(bytes.TrimSuffix(out, []byte("\n"))), err
+}
+
+// sameShell compare node without its content
+func sameShell(a *yaml.Node, b *yaml.Node) bool {
+	return a.Kind == b.Kind && a.Style == b.Style && a.ShortTag() == b.ShortTag() && a.Value == b.Value &&
+		a.Anchor == b.Anchor && a.HeadComment == b.HeadComment && a.LineComment == b.LineComment && a.FootComment == b.FootComment
+}
+
+// sameTree compare node and all its descendant, positions are ignored
+func sameTree(a *yaml.Node, b *yaml.Node) bool {
+	if !sameShell(a, b) || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !sameTree(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package yquery_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var minimalData = `# odd   spacing and alignment are kept
+name:   web          # aligned comment
+port:   80           # aligned comment
+
+spec:
+  replicas: 1
+  # head of image
+  image: 'nginx:1.0'
+  env:
+    - name: A
+      value: "1"
+
+    - name: B
+      value: "2"
+  flow: {a: 1, b: 2}
+removed: true
+
+tail: end
+`
+
+func TestMarshalMinimal(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(minimalData))
+	out, err := yq.MarshalMinimal()
+	asserts.NoError(err)
+	asserts.Equal(minimalData, string(out))
+
+	asserts.NoError(yq.Set("port", "8080"))
+	asserts.NoError(yq.Set("spec.image", "nginx:2.0"))
+	asserts.NoError(yq.Set("spec.env[1].value", "3"))
+	asserts.NoError(yq.Set("spec.flow.c", "3"))
+	asserts.NoError(yq.Set("spec.added", "new"))
+	asserts.NoError(yq.Delete("removed"))
+	asserts.NoError(yq.Delete("spec.env[0]"))
+	out, err = yq.MarshalMinimal()
+	asserts.NoError(err)
+	// language=yaml
+	asserts.Equal(`# odd   spacing and alignment are kept
+name:   web          # aligned comment
+port:   8080           # aligned comment
+
+spec:
+  replicas: 1
+  # head of image
+  image: 'nginx:2.0'
+  env:
+    - name: B
+      value: "3"
+  flow: {a: 1, b: 2, c: 3}
+  added: new
+
+tail: end
+`, string(out))
+}
+
+func TestMarshalMinimalFallback(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte("a:  1\nb: [1, 2]\n"))
+	asserts.NoError(yq.Set("b", "{c: 1}"))
+	out, err := yq.MarshalMinimal()
+	asserts.NoError(err)
+	asserts.Equal("a:  1\nb: {c: 1}\n", string(out))
+
+	yq, _ = yquery.Unmarshal([]byte("a:  1\nb: 2\n"))
+	asserts.NoError(yq.Canonicalize(yquery.CanonicalizeConfig{KeyOrder: []string{"b"}}))
+	out, err = yq.MarshalMinimal()
+	asserts.NoError(err)
+	asserts.Equal("b: 2\na: 1\n", string(out))
+
+	// format is detected from the source without Load
+	yq, _ = yquery.Unmarshal([]byte("a:\n  b: 1\nc:\n- 1\n"))
+	asserts.NoError(yq.Set("a", "{d: {e: 1}}"))
+	asserts.NoError(yq.Set("f", "[1, 2]", yquery.Config{Style: yquery.BlockStyle}))
+	out, err = yq.MarshalMinimal()
+	asserts.NoError(err)
+	asserts.Equal("a:\n  d: {e: 1}\nc:\n- 1\nf:\n- 1\n- 2\n", string(out))
+
+	_, err = (&yquery.YQuery{}).MarshalMinimal()
+	asserts.Error(err)
+}
+
+func TestSaveMinimalDiff(t *testing.T) {
+	asserts := assert.New(t)
+	dir, err := ioutil.TempDir("", "yquery")
+	asserts.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.yaml")
+	data := "list:\r\n- a   # a\r\n- b\r\nkey:  value"
+	asserts.NoError(ioutil.WriteFile(path, []byte(data), 0644))
+	yq, _ := yquery.Load(path)
+	asserts.NoError(yq.Set("list[2]", "c"))
+	asserts.NoError(yq.Save(yquery.SaveConfig{MinimalDiff: true}))
+	out, _ := ioutil.ReadFile(path)
+	asserts.Equal("list:\r\n- a   # a\r\n- b\r\n- c\r\nkey:  value", string(out))
+
+	asserts.NoError(yq.Set("key", "new value"))
+	asserts.NoError(yq.Save(yquery.SaveConfig{MinimalDiff: true}))
+	out, _ = ioutil.ReadFile(path)
+	asserts.Equal("list:\r\n- a   # a\r\n- b\r\n- c\r\nkey:  new value", string(out))
+}
+
+func TestMarshalMinimalSequenceItem(t *testing.T) {
+	asserts := assert.New(t)
+	tests := []struct {
+		data   string
+		modify func(yq *yquery.YQuery) error
+		out    string
+	}{
+		{"- k: v\n  j: w\n", func(yq *yquery.YQuery) error {
+			return yq.SetComment("[0].k", yquery.LineComment, "lc")
+		}, "- k: v # lc\n  j: w\n"},
+		{"- {n: 1}\n- k: v\n  j: w\n", func(yq *yquery.YQuery) error {
+			return yq.SetComment("[1].k", yquery.LineComment, "lc")
+		}, "- {n: 1}\n- k: v # lc\n  j: w\n"},
+		{"- k: v\n  j: w\n", func(yq *yquery.YQuery) error {
+			return yq.Move("[0].k", "[0].z")
+		}, "- j: w\n  z: v\n"},
+		{"- k: v\n  j: w\n", func(yq *yquery.YQuery) error {
+			return yq.Set("[0].k", "{x: 1}", yquery.Config{Style: yquery.BlockStyle})
+		}, "- k:\n      x: 1\n  j: w\n"},
+		{"- k: v\n  j: w\n", func(yq *yquery.YQuery) error {
+			return yq.Delete("[0].k")
+		}, "- j: w\n"},
+		{"- - a\n  - b\n", func(yq *yquery.YQuery) error {
+			return yq.SetComment("[0][0]", yquery.LineComment, "lc")
+		}, "- - a # lc\n  - b\n"},
+		{"- - a\n  - b\n", func(yq *yquery.YQuery) error {
+			return yq.Delete("[0][0]")
+		}, "- - b\n"},
+	}
+	for _, test := range tests {
+		yq, _ := yquery.Unmarshal([]byte(test.data))
+		asserts.NoError(test.modify(yq))
+		out, err := yq.MarshalMinimal()
+		asserts.NoError(err)
+		asserts.Equal(test.out, string(out), test.data)
+		_, err = yquery.Unmarshal(out)
+		asserts.NoError(err)
+	}
+}
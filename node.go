@@ -0,0 +1,227 @@
+package yquery
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// functions handle yaml node
+
+// resolveAlias return the node an alias points to, or the node itself if it is not an alias
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// copyNode make a deep copy of node
+// Anchor definitions are removed from the copy to prevent duplicated anchors in one document,
+// alias nodes inside still point to the original anchor.
+func copyNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	n := *node
+	n.Anchor = ""
+	if node.Content != nil {
+		n.Content = make([]*yaml.Node, len(node.Content))
+		for i, content := range node.Content {
+			n.Content[i] = copyNode(content)
+		}
+	}
+	return &n
+}
+
+// locate find the node of parser string, return its parent node and index in parent's content
+// When follow is false, it does not go through anchor reference or merge item,
+// because the node returned is going to be modified.
+// When follow is true, parent could be the anchor or the merge item that holds the node.
+func (y *YQuery) locate(slices []string, delimiter string, follow bool) (*yaml.Node, int, error) {
+	var parent *yaml.Node
+	index := -1
+	node := y.RootNode
+	if len(slices) == 0 {
+		return nil, 0, fmt.Errorf("the item is the root of document, which has no parent")
+	}
+	if node == nil {
+		return nil, 0, fmt.Errorf("cannot find item %s, the document is empty", strings.Join(slices, delimiter))
+	}
+	for i, slice := range slices {
+		if node.Alias != nil {
+			if !follow {
+				return nil, 0, fmt.Errorf("the item '%s' reaches an anchor reference. You can not modify value from anchor reference", strings.Join(slices[:i], delimiter))
+			}
+			node = resolveAlias(node)
+		}
+		parent = node
+		switch node.Tag {
+		case seqTag:
+			seqID, err := getSequenceNum(slice)
+			if err != nil {
+				return nil, 0, err
+			}
+			if seqID >= len(node.Content) {
+				return nil, 0, fmt.Errorf("the item %s cannot found. Index out of range", strings.Join(slices[:i+1], delimiter))
+			}
+			index = seqID
+		case mapTag:
+			parent, index = findEntry(node, slice)
+			if parent == nil {
+				return nil, 0, fmt.Errorf("cannot find item %s", strings.Join(slices[:i+1], delimiter))
+			}
+			if parent != node && !follow {
+				return nil, 0, fmt.Errorf("the item '%s' comes from a merge key", strings.Join(slices[:i+1], delimiter))
+			}
+		default:
+			return nil, 0, fmt.Errorf("unable continue to parse item %s, get value: %s", strings.Join(slices[:i], delimiter), node.Value)
+		}
+		node = parent.Content[index]
+	}
+	return parent, index, nil
+}
+
+// walkNode call fn on node and all its descendant, alias target is not followed
+func walkNode(node *yaml.Node, fn func(node *yaml.Node)) {
+	if node == nil {
+		return
+	}
+	fn(node)
+	for _, content := range node.Content {
+		walkNode(content, fn)
+	}
+}
+
+// orderAnchors move anchors before their references in document order
+// A reference before its anchor is not valid yaml, it happens when items are reordered or moved.
+// The anchored node is swapped with its first reference.
+func orderAnchors(root *yaml.Node) {
+	for {
+		seen := map[*yaml.Node]bool{}
+		var alias, aliasParent *yaml.Node
+		aliasIndex := -1
+		var visit func(parent *yaml.Node, index int, node *yaml.Node)
+		visit = func(parent *yaml.Node, index int, node *yaml.Node) {
+			if alias != nil {
+				return
+			}
+			seen[node] = true
+			if node.Kind == yaml.AliasNode && node.Alias != nil && !seen[node.Alias] {
+				alias, aliasParent, aliasIndex = node, parent, index
+				return
+			}
+			for i, content := range node.Content {
+				visit(node, i, content)
+			}
+		}
+		if root != nil {
+			visit(nil, -1, root)
+		}
+		if alias == nil {
+			return
+		}
+		target := alias.Alias
+		walkNode(root, func(n *yaml.Node) {
+			for i, content := range n.Content {
+				if content == target {
+					n.Content[i] = alias
+				}
+			}
+		})
+		aliasParent.Content[aliasIndex] = target
+	}
+}
+
+// cloneNode make a deep copy of node and all its descendant
+// Different from copyNode, anchors are kept, and anchor references inside point to the copied anchors.
+func cloneNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	copies := map[*yaml.Node]*yaml.Node{}
+	var clone func(node *yaml.Node) *yaml.Node
+	clone = func(node *yaml.Node) *yaml.Node {
+		if n, ok := copies[node]; ok {
+			return n
+		}
+		n := *node
+		copies[node] = &n
+		if node.Content != nil {
+			n.Content = make([]*yaml.Node, len(node.Content))
+			for i, content := range node.Content {
+				n.Content[i] = clone(content)
+			}
+		}
+		return &n
+	}
+	root := clone(node)
+	for _, n := range copies {
+		if target, ok := copies[n.Alias]; ok {
+			n.Alias = target
+		}
+	}
+	return root
+}
+
+// locateNode find the node of parser string without going through anchor reference or merge item
+// Root node is returned when slices is empty.
+func (y *YQuery) locateNode(slices []string, delimiter string) (*yaml.Node, error) {
+	if len(slices) == 0 {
+		return y.RootNode, nil
+	}
+	parent, index, err := y.locate(slices, delimiter, false)
+	if err != nil {
+		return nil, err
+	}
+	return parent.Content[index], nil
+}
+
+// removeContent remove the item at index from parent, key is removed as well for mapping
+// It returns the key node removed, which is nil for sequence.
+func removeContent(parent *yaml.Node, index int) *yaml.Node {
+	if parent.Kind == yaml.MappingNode {
+		key := parent.Content[index-1]
+		parent.Content = append(parent.Content[:index-1:index-1], parent.Content[index+1:]...)
+		return key
+	}
+	parent.Content = append(parent.Content[:index:index], parent.Content[index+1:]...)
+	return nil
+}
+
+// insertContent put node into container with the key or index in slice
+// Existing mapping item is replaced, and sequence item is inserted before the one at index.
+// keyNode is used as the key when a new mapping item is added, a new one is created if it is nil.
+func insertContent(container *yaml.Node, slice string, node *yaml.Node, keyNode *yaml.Node) error {
+	switch container.Kind {
+	case yaml.SequenceNode:
+		index, err := getSequenceNum(slice)
+		if err != nil {
+			return err
+		}
+		if index > len(container.Content) {
+			return fmt.Errorf("index %d is out of range while it only has %d item", index, len(container.Content))
+		}
+		content := append(container.Content[:index:index], node)
+		container.Content = append(content, container.Content[index:]...)
+	case yaml.MappingNode:
+		for index := 0; index+1 < len(container.Content); index += 2 {
+			if container.Content[index].Tag != mergeTag && container.Content[index].Value == slice {
+				container.Content[index+1] = node
+				return nil
+			}
+		}
+		if keyNode == nil || keyNode.Value != slice {
+			keyNode = &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Tag:   strTag,
+				Value: slice,
+			}
+		}
+		container.Content = append(container.Content, keyNode, node)
+	default:
+		return fmt.Errorf("cannot put item '%s' into a scalar", slice)
+	}
+	return nil
+}
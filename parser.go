@@ -31,7 +31,13 @@ func getDelimiter(option []Config) (string, error) {
 	return ".", nil
 }
 
+// getParserSlice split parser string into slices, e.g. "a.b[0]" into "a", "b", "[0]"
+// Parser string of a sequence root starts with index, e.g. "[0].a" into "[0]", "a".
 func getParserSlice(parser string, delimiter string) []string {
+	root := strings.HasPrefix(parser, "[")
 	parser = strings.Replace(parser, "[", delimiter+"[", -1)
+	if root {
+		parser = strings.TrimPrefix(parser, delimiter)
+	}
 	return strings.Split(parser, delimiter)
 }
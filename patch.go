@@ -0,0 +1,342 @@
+package yquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonPatchOperation is one operation of RFC 6902 JSON Patch
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// pointerDelimiter is used to join slices of JSON Pointer in error message
+const pointerDelimiter = "/"
+
+// ApplyJSONPatch apply RFC 6902 JSON Patch to the document
+// Supported operations are "add", "remove", "replace", "move", "copy" and "test".
+//     [
+//       {"op": "replace", "path": "/a/b", "value": 42},
+//       {"op": "add", "path": "/c/-", "value": {"d": "new item"}}
+//     ]
+//
+// Paths are JSON Pointers (RFC 6901), anchor references and merge items are followed when reading,
+// but cannot be modified, same as Set.
+// Comments, key order and anchors of items not touched are kept, and replaced value keeps its comments.
+// Values keep their JSON types, the style of a replaced value is kept only when both are strings.
+// "test" compares numbers by value, e.g. 1 equals to 1.0.
+// It applies all operations or none of them, the error returned tells the index of the failed operation.
+func (y *YQuery) ApplyJSONPatch(patch []byte) error {
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return fmt.Errorf("invalid json patch: %s", err)
+	}
+	operation := Operation{Name: "ApplyJSONPatch"}
+	for _, o := range operations {
+		if o.From != "" {
+			operation.Paths = append(operation.Paths, o.From)
+		}
+		operation.Paths = append(operation.Paths, o.Path)
+	}
+	return y.modify(operation, func() error {
+		c := y.clone()
+		for i, o := range operations {
+			if err := c.applyJSONPatchOperation(o); err != nil {
+				return fmt.Errorf("json patch operation %d (%s %s) failed: %s", i, o.Op, o.Path, err)
+			}
+		}
+		y.restore(c)
+		return nil
+	})
+}
+
+func (y *YQuery) applyJSONPatchOperation(o jsonPatchOperation) error {
+	path, err := y.pointerSlices(o.Path)
+	if err != nil {
+		return err
+	}
+	switch o.Op {
+	case "add":
+		value, err := jsonValueNode(o.Value)
+		if err != nil {
+			return err
+		}
+		return y.addNode(path, value)
+	case "remove":
+		if len(path) == 0 {
+			return fmt.Errorf("cannot remove the whole document")
+		}
+		return y.deleteNode(path, pointerDelimiter)
+	case "replace":
+		value, err := jsonValueNode(o.Value)
+		if err != nil {
+			return err
+		}
+		if len(path) == 0 {
+			y.RootNode = value
+			return nil
+		}
+		parent, index, err := y.locate(path, pointerDelimiter, false)
+		if err != nil {
+			return err
+		}
+		return replaceTypedValue(parent.Content[index], value)
+	case "move":
+		from, err := y.pointerSlices(o.From)
+		if err != nil {
+			return err
+		}
+		if o.From == o.Path {
+			// moving an item to itself changes nothing, though it must exist
+			_, err := y.followNode(from)
+			return err
+		}
+		return y.moveNode(from, path, pointerDelimiter)
+	case "copy":
+		from, err := y.pointerSlices(o.From)
+		if err != nil {
+			return err
+		}
+		node, err := y.followNode(from)
+		if err != nil {
+			return err
+		}
+		return y.addNode(path, copyNode(resolveAlias(node)))
+	case "test":
+		node, err := y.followNode(path)
+		if err != nil {
+			return err
+		}
+		var actual, expected interface{}
+		if err := node.Decode(&actual); err != nil {
+			return err
+		}
+		value, err := jsonValueNode(o.Value)
+		if err != nil {
+			return err
+		}
+		if err := value.Decode(&expected); err != nil {
+			return err
+		}
+		if !jsonEqual(actual, expected) {
+			return fmt.Errorf("value is %#v, not %#v", actual, expected)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown operation '%s'", o.Op)
+	}
+}
+
+// addNode put node to path, existing mapping item is replaced, and sequence item is inserted
+func (y *YQuery) addNode(path []string, node *yaml.Node) error {
+	if len(path) == 0 {
+		y.RootNode = node
+		return nil
+	}
+	container, err := y.locateNode(path[:len(path)-1], pointerDelimiter)
+	if err != nil {
+		return err
+	}
+	if container == nil {
+		return fmt.Errorf("the document is empty")
+	}
+	return insertContent(container, path[len(path)-1], node, nil)
+}
+
+// followNode find the node of path, going through anchor reference and merge item
+func (y *YQuery) followNode(path []string) (*yaml.Node, error) {
+	if len(path) == 0 {
+		return y.RootNode, nil
+	}
+	parent, index, err := y.locate(path, pointerDelimiter, true)
+	if err != nil {
+		return nil, err
+	}
+	return parent.Content[index], nil
+}
+
+// pointerSlices convert JSON Pointer to slices of parser string
+// Whether a reference token is an index or a key depends on the node it applies to,
+// "-" refers to the position after the last item of a sequence.
+func (y *YQuery) pointerSlices(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer '%s'", pointer)
+	}
+	if y.RootNode == nil {
+		return nil, fmt.Errorf("cannot find item '%s', the document is empty", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	slices := make([]string, 0, len(tokens))
+	node := y.RootNode
+	for i, token := range tokens {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		node = resolveAlias(node)
+		slice := token
+		if node.Kind == yaml.SequenceNode {
+			index, err := strconv.Atoi(token)
+			switch {
+			case token == "-":
+				index = len(node.Content)
+			case err != nil || index < 0 || strconv.Itoa(index) != token:
+				return nil, fmt.Errorf("invalid index '%s' in json pointer '%s'", token, pointer)
+			}
+			slice = fmt.Sprintf("[%d]", index)
+		}
+		slices = append(slices, slice)
+		if i == len(tokens)-1 {
+			break
+		}
+		next, err := y.followNode(slices)
+		if err != nil {
+			return nil, err
+		}
+		node = next
+	}
+	return slices, nil
+}
+
+// jsonValueNode parse JSON value to node
+// Keys keep their order and are always strings, e.g. "<<" is not a merge key. Numbers keep their text.
+// Duplicated keys and numbers out of the range of float are errors.
+func jsonValueNode(value json.RawMessage) (*yaml.Node, error) {
+	if value == nil {
+		return nil, fmt.Errorf("value is missing")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	node, err := decodeJSONNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid data after the value at offset %d", decoder.InputOffset())
+	}
+	return node, nil
+}
+
+// decodeJSONNode read the next value from decoder as node
+func decodeJSONNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: seqTag}
+		if t == '{' {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag}
+		}
+		keys := map[string]bool{}
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				// keys of object are always strings
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				if keys[key.(string)] {
+					return nil, fmt.Errorf("duplicated key %q", key)
+				}
+				keys[key.(string)] = true
+				node.Content = append(node.Content, jsonStringNode(key.(string)))
+			}
+			item, err := decodeJSONNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, item)
+		}
+		// the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return jsonStringNode(t), nil
+	case json.Number:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: t.String()}
+		if node.Tag = implicitTag(node); node.Tag != intTag && node.Tag != floatTag {
+			return nil, fmt.Errorf("number %s is out of range", t)
+		}
+		return node, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: boolTag, Value: strconv.FormatBool(t)}, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: nullTag, Value: "null"}, nil
+}
+
+// jsonStringNode return node of JSON string
+// It is quoted if it would not be read back as a string in plain style, e.g. "true", "<<" or a number out of range.
+func jsonStringNode(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: strTag, Value: value}
+	if value == "<<" || jsonNumber.MatchString(value) || implicitTag(&yaml.Node{Kind: yaml.ScalarNode, Value: value}) != strTag {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node
+}
+
+// jsonEqual compare decoded values as the test operation, numbers are equal if their values are equal, e.g. 1 and 1.0
+func jsonEqual(a interface{}, b interface{}) bool {
+	if x, ok := jsonNumberValue(a); ok {
+		y, ok := jsonNumberValue(b)
+		return ok && x.Cmp(y) == 0
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			if other, ok := b[key]; !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// jsonNumberValue return the exact value of a decoded number
+func jsonNumberValue(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case int:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	case uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(n)), true
+	case float64:
+		if math.IsInf(n, 0) || math.IsNaN(n) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(n), true
+	}
+	return nil, false
+}
@@ -0,0 +1,125 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var patchData = `# head of a
+a: "value of a" # line of a
+b:
+  - 1
+  - 2
+c: &c
+  d: value of d
+  e/f: slash
+  g~h: tilde
+i: *c
+j:
+  <<: *c
+`
+
+func TestApplyJSONPatch(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(patchData))
+	yq.SetHistoryDepth(1)
+	err := yq.ApplyJSONPatch([]byte(`[
+		{"op": "test", "path": "/a", "value": "value of a"},
+		{"op": "replace", "path": "/a", "value": "new a"},
+		{"op": "add", "path": "/b/1", "value": 3},
+		{"op": "add", "path": "/b/-", "value": {"k": [true, null]}},
+		{"op": "remove", "path": "/b/0"},
+		{"op": "test", "path": "/c/e~1f", "value": "slash"},
+		{"op": "replace", "path": "/c/g~0h", "value": "new tilde"},
+		{"op": "test", "path": "/i/g~0h", "value": "new tilde"},
+		{"op": "test", "path": "/j/d", "value": "value of d"},
+		{"op": "copy", "from": "/j/d", "path": "/j/copied"},
+		{"op": "move", "from": "/c/e~1f", "path": "/moved"},
+		{"op": "add", "path": "/l", "value": null}
+	]`))
+	asserts.NoError(err)
+	testCases := []casePair{
+		{"a", "new a"},
+		{"b[0]", "3"},
+		{"b[1]", "2"},
+		{"b[2].k[0]", "true"},
+		{"i.g~h", "new tilde"},
+		{"j.copied", "value of d"},
+		{"moved", "slash"},
+	}
+	for _, c := range testCases {
+		res, err := yq.Get(c.Parser)
+		asserts.NoError(err, c.Parser)
+		asserts.Equal(c.Value, res, c.Parser)
+	}
+	out, _ := yq.Marshal()
+	asserts.Contains(string(out), "# head of a\na: \"new a\" # line of a\n")
+	asserts.Contains(string(out), "c: &c\n")
+	asserts.Contains(string(out), "l: null\n")
+	asserts.Equal("ApplyJSONPatch", yq.History()[0].Name)
+	asserts.Equal([]string{"/a", "/a", "/b/1", "/b/-", "/b/0", "/c/e~1f", "/c/g~0h", "/i/g~0h", "/j/d", "/j/d", "/j/copied", "/c/e~1f", "/moved", "/l"},
+		yq.History()[0].Paths)
+}
+
+func TestApplyJSONPatchValueType(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte("a: \"1\" # quoted\nb: !!str 2\nc: 1\n"))
+	err := yq.ApplyJSONPatch([]byte(`[
+		{"op": "replace", "path": "/a", "value": 42},
+		{"op": "test", "path": "/a", "value": 42},
+		{"op": "replace", "path": "/b", "value": true},
+		{"op": "test", "path": "/b", "value": true},
+		{"op": "test", "path": "/c", "value": 1.0},
+		{"op": "test", "path": "/c", "value": 1e0}
+	]`))
+	asserts.NoError(err)
+	out, _ := yq.Marshal()
+	asserts.Equal("a: 42 # quoted\nb: true\nc: 1\n", string(out))
+}
+
+func TestApplyJSONPatchMoveToItself(t *testing.T) {
+	asserts := assert.New(t)
+	data := "a: {b: 1}\nz: 2\nl: [1, 2]\n"
+	yq, _ := yquery.Unmarshal([]byte(data))
+	asserts.NoError(yq.ApplyJSONPatch([]byte(`[{"op": "move", "from": "/a", "path": "/a"}, {"op": "move", "from": "/l/0", "path": "/l/0"}]`)))
+	out, _ := yq.Marshal()
+	asserts.Equal(data, string(out))
+}
+
+func TestApplyJSONPatchShouldError(t *testing.T) {
+	asserts := assert.New(t)
+	testCases := []struct {
+		Patch   string
+		Message string
+	}{
+		{`not json`, "invalid json patch"},
+		{`[{"op": "replace", "path": "/a", "value": "new a"}, {"op": "test", "path": "/a", "value": "value of a"}]`, "operation 1 (test /a)"},
+		{`[{"op": "remove", "path": "/notExist"}]`, "operation 0"},
+		{`[{"op": "add", "path": "/b/3", "value": 1}]`, "operation 0"},
+		{`[{"op": "add", "path": "/b/01", "value": 1}]`, "operation 0"},
+		{`[{"op": "add", "path": "/a"}]`, "value is missing"},
+		{`[{"op": "replace", "path": "/i/d", "value": 1}]`, "anchor reference"},
+		{`[{"op": "remove", "path": "/c"}]`, "still referenced"},
+		{`[{"op": "move", "from": "/c", "path": "/c/x"}]`, "into itself"},
+		{`[{"op": "move", "from": "/notExist", "path": "/notExist"}]`, "operation 0"},
+		{`[{"op": "test", "path": "/a", "value": 1}]`, `value is "value of a", not 1`},
+		{`[{"op": "test", "path": "/b/0", "value": "1"}]`, `value is 1, not "1"`},
+		{`[{"op": "test", "path": "/b/0", "value": 1.5}]`, "not 1.5"},
+		{`[{"op": "unknown", "path": "/a"}]`, "unknown operation"},
+		{`[{"op": "add", "path": "a", "value": 1}]`, "invalid json pointer"},
+	}
+	for _, c := range testCases {
+		yq, _ := yquery.Unmarshal([]byte(patchData))
+		origin, _ := yq.Marshal()
+		err := yq.ApplyJSONPatch([]byte(c.Patch))
+		if asserts.Error(err, c.Patch) {
+			asserts.Contains(err.Error(), c.Message)
+		}
+		out, _ := yq.Marshal()
+		asserts.Equal(string(origin), string(out))
+	}
+}
@@ -0,0 +1,375 @@
+package yquery
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// functions handle set
+
+// ValueStyle is the style of value written by Set
+// Scalar styles only apply to scalar value, and flow or block style only apply to mapping and sequence.
+type ValueStyle int
+
+const (
+	// KeepStyle keep the style of the scalar value replaced, new value use the style parsed from value string
+	KeepStyle ValueStyle = iota
+	// ParsedStyle always use the style parsed from value string
+	ParsedStyle
+	// PlainStyle write scalar without quote
+	PlainStyle
+	// SingleQuotedStyle write scalar in single quote, e.g. 'value'
+	SingleQuotedStyle
+	// DoubleQuotedStyle write scalar in double quote, e.g. "value"
+	DoubleQuotedStyle
+	// LiteralStyle write scalar as literal block, e.g. |
+	LiteralStyle
+	// FoldedStyle write scalar as folded block, e.g. >
+	FoldedStyle
+	// FlowStyle write mapping or sequence in flow style, e.g. {a: 1} or [1, 2]
+	FlowStyle
+	// BlockStyle write mapping or sequence in block style, one item per line
+	BlockStyle
+)
+
+var yamlStyles = map[ValueStyle]yaml.Style{
+	PlainStyle:        0,
+	SingleQuotedStyle: yaml.SingleQuotedStyle,
+	DoubleQuotedStyle: yaml.DoubleQuotedStyle,
+	LiteralStyle:      yaml.LiteralStyle,
+	FoldedStyle:       yaml.FoldedStyle,
+}
+
+var scalarStyles = map[yaml.Style]ValueStyle{
+	0:                      PlainStyle,
+	yaml.SingleQuotedStyle: SingleQuotedStyle,
+	yaml.DoubleQuotedStyle: DoubleQuotedStyle,
+	yaml.LiteralStyle:      LiteralStyle,
+	yaml.FoldedStyle:       FoldedStyle,
+}
+
+func (y *YQuery) setNodeValue(key string, value string) parseResult {
+	// todo: handler complex value
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(key+": "+value), &node)
+	if err != nil {
+		// not one line
+		// change to new line and padding
+		value := "\n  " + strings.Replace(value, "\n", "\n  ", -1)
+		err = yaml.Unmarshal([]byte(key+": "+value), &node)
+	}
+	return parseResult{&node, err}
+}
+
+// setNode go along the parser string and set value to the last one
+// Missing node in the middle of the path is created when Recursive is set.
+// When it fails, the document is left unchanged.
+func (y *YQuery) setNode(slices []string, delimiter string, currentNode *yaml.Node, i int, parameter parseParameter) parseResult {
+	if currentNode.Alias != nil {
+		return parseResult{currentNode, fmt.Errorf("the item '%s' reaches an anchor reference. You can not modify value from anchor reference", strings.Join(slices[:i], delimiter))}
+	}
+	if slices[i] == "" {
+		return parseResult{currentNode, fmt.Errorf("cannot parse on blank item '%s'", strings.Join(slices[:i], delimiter))}
+	}
+	if currentNode.Kind != yaml.SequenceNode && currentNode.Kind != yaml.MappingNode {
+		// literal node need to change to struct
+		if i < len(slices)-1 && !parameter.Recursive {
+			return parseResult{currentNode, fmt.Errorf("internal item '%s' not exists", strings.Join(slices[:i+1], delimiter))}
+		}
+		return y.setInNewNode(slices, delimiter, currentNode, i, parameter)
+	}
+	if i == len(slices)-1 {
+		return y.setLeaf(slices, delimiter, currentNode, i, parameter)
+	}
+
+	switch currentNode.Kind {
+	case yaml.SequenceNode:
+		index, err := getSequenceNum(slices[i])
+		if err != nil {
+			return parseResult{currentNode, err}
+		}
+		if index >= len(currentNode.Content) {
+			if !parameter.Recursive {
+				return parseResult{currentNode, fmt.Errorf("the item %s cannot found. Index out of range", strings.Join(slices[:i+1], delimiter))}
+			}
+			return y.setInNewItem(slices, delimiter, currentNode, index, i, parameter)
+		}
+		parameter.ParentNode = currentNode
+		parameter.Index = index
+		return y.setNode(slices, delimiter, currentNode.Content[index], i+1, parameter)
+	default:
+		for index := 0; index+1 < len(currentNode.Content); index += 2 {
+			if currentNode.Content[index].Tag != mergeTag && currentNode.Content[index].Value == slices[i] {
+				parameter.ParentNode = currentNode
+				parameter.Index = index + 1
+				return y.setNode(slices, delimiter, currentNode.Content[index+1], i+1, parameter)
+			}
+		}
+		if inherited := findInMerge(currentNode, slices[i]); inherited != nil {
+			return y.setInMerge(slices, delimiter, currentNode, inherited, i, parameter)
+		}
+		if !parameter.Recursive {
+			return parseResult{currentNode, fmt.Errorf("internal item '%s' not exists", strings.Join(slices[:i+1], delimiter))}
+		}
+		return y.setInNewKey(slices, delimiter, currentNode, newContainerNode(slices[i+1]), i, parameter)
+	}
+}
+
+// setLeaf set value to the last item of parser string, currentNode should be a sequence or mapping
+func (y *YQuery) setLeaf(slices []string, delimiter string, currentNode *yaml.Node, i int, parameter parseParameter) parseResult {
+	result := y.setNodeValue(slices[i], parameter.Value)
+	if result.Err != nil {
+		return parseResult{currentNode, result.Err}
+	}
+	keyNode := result.Node.Content[0].Content[0]
+	valueNode := result.Node.Content[0].Content[1]
+	if currentNode.Kind == yaml.SequenceNode {
+		index, err := getSequenceNum(slices[i])
+		if err != nil {
+			return parseResult{currentNode, err}
+		}
+		if index < len(currentNode.Content) {
+			if err := replaceValue(currentNode.Content[index], valueNode, parameter.Config); err != nil {
+				return parseResult{currentNode, err}
+			}
+			setComment(nil, currentNode.Content[index], parameter.Comment)
+			return parseResult{currentNode, nil}
+		}
+		// add new item
+		if err := formatValue(valueNode, parameter.Style, parameter.Tag); err != nil {
+			return parseResult{currentNode, err}
+		}
+		if err := padSequence(currentNode, index, parameter.PadSequence); err != nil {
+			return parseResult{currentNode, fmt.Errorf("cannot set item %s: %s", strings.Join(slices[:i+1], delimiter), err)}
+		}
+		setComment(nil, valueNode, parameter.Comment)
+		currentNode.Content = append(currentNode.Content, valueNode)
+		return parseResult{currentNode, nil}
+	}
+	for index := 0; index+1 < len(currentNode.Content); index += 2 {
+		if currentNode.Content[index].Tag != mergeTag && currentNode.Content[index].Value == slices[i] {
+			if err := replaceValue(currentNode.Content[index+1], valueNode, parameter.Config); err != nil {
+				return parseResult{currentNode, err}
+			}
+			setComment(currentNode.Content[index], currentNode.Content[index+1], parameter.Comment)
+			return parseResult{currentNode, nil}
+		}
+	}
+	// key not exists
+	if err := formatValue(valueNode, parameter.Style, parameter.Tag); err != nil {
+		return parseResult{currentNode, err}
+	}
+	setComment(keyNode, valueNode, parameter.Comment)
+	currentNode.Content = append(currentNode.Content, keyNode, valueNode)
+	return parseResult{currentNode, nil}
+}
+
+// setInNewNode replace literal node with a new sequence or mapping, and set value in it
+func (y *YQuery) setInNewNode(slices []string, delimiter string, currentNode *yaml.Node, i int, parameter parseParameter) parseResult {
+	node := newContainerNode(slices[i])
+	y.replaceNode(parameter, node)
+	result := y.setNode(slices, delimiter, node, i, parameter)
+	if result.Err != nil {
+		// keep the node unchanged when failed
+		y.replaceNode(parameter, currentNode)
+	}
+	return result
+}
+
+// setInNewItem append a new item to sequence at index, and set value in it
+func (y *YQuery) setInNewItem(slices []string, delimiter string, currentNode *yaml.Node, index int, i int, parameter parseParameter) parseResult {
+	content := currentNode.Content
+	if err := padSequence(currentNode, index, parameter.PadSequence); err != nil {
+		return parseResult{currentNode, fmt.Errorf("cannot create item %s: %s", strings.Join(slices[:i+1], delimiter), err)}
+	}
+	node := newContainerNode(slices[i+1])
+	currentNode.Content = append(currentNode.Content, node)
+	parameter.ParentNode = currentNode
+	parameter.Index = index
+	result := y.setNode(slices, delimiter, node, i+1, parameter)
+	if result.Err != nil {
+		// keep the node unchanged when failed
+		currentNode.Content = content
+	}
+	return result
+}
+
+// setInNewKey append a new key with valueNode to mapping, and set value in it
+func (y *YQuery) setInNewKey(slices []string, delimiter string, currentNode *yaml.Node, valueNode *yaml.Node, i int, parameter parseParameter) parseResult {
+	keyNode := &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   strTag,
+		Value: slices[i],
+	}
+	content := currentNode.Content
+	currentNode.Content = append(currentNode.Content, keyNode, valueNode)
+	parameter.ParentNode = currentNode
+	parameter.Index = len(currentNode.Content) - 1
+	result := y.setNode(slices, delimiter, valueNode, i+1, parameter)
+	if result.Err != nil {
+		// keep the node unchanged when failed
+		currentNode.Content = content
+	}
+	return result
+}
+
+func (y *YQuery) setInMerge(slices []string, delimiter string, currentNode *yaml.Node, inherited *yaml.Node, i int, parameter parseParameter) parseResult {
+	switch {
+	case parameter.MaterializeMerge:
+		return y.setInNewKey(slices, delimiter, currentNode, materializeMergeItem(inherited), i, parameter)
+	case parameter.ForceInMerge:
+		return y.setInNewKey(slices, delimiter, currentNode, newContainerNode(slices[i+1]), i, parameter)
+	default:
+		return parseResult{currentNode, fmt.Errorf("the item '%s' comes from a merge key. Set MaterializeMerge or ForceInMerge to override it", strings.Join(slices[:i+1], delimiter))}
+	}
+}
+
+// replaceValue replace old value node with node in place, so that anchor references to it follow the new value
+// Anchor, comments, and explicit tag of old node are kept.
+// When style is KeepStyle, the style of old node is kept as well.
+func replaceValue(old *yaml.Node, node *yaml.Node, config Config) error {
+	if node.Anchor == "" {
+		node.Anchor = old.Anchor
+	}
+	if node.HeadComment == "" {
+		node.HeadComment = old.HeadComment
+	}
+	if node.LineComment == "" {
+		node.LineComment = old.LineComment
+	}
+	if node.FootComment == "" {
+		node.FootComment = old.FootComment
+	}
+	style := config.Style
+	if old.Kind == yaml.ScalarNode && node.Kind == yaml.ScalarNode && node.Style&yaml.TaggedStyle == 0 {
+		if old.Style&yaml.TaggedStyle != 0 {
+			node.Tag = old.Tag
+			node.Style |= yaml.TaggedStyle
+		}
+		if style == KeepStyle {
+			style = scalarStyles[old.Style&^yaml.TaggedStyle]
+		}
+	}
+	if old.Kind == node.Kind && node.Kind != yaml.ScalarNode && style == KeepStyle {
+		style = BlockStyle
+		if old.Style&yaml.FlowStyle != 0 {
+			style = FlowStyle
+		}
+	}
+	if err := formatValue(node, style, config.Tag); err != nil {
+		return err
+	}
+	*old = *node
+	return nil
+}
+
+// replaceTypedValue replace old value node with node which has its own type, e.g. a JSON value or a value of patch
+// Anchor and comments of old node are kept. Its explicit tag is dropped, and the style of a scalar is kept only when both are strings,
+// so the type of node is never changed.
+func replaceTypedValue(old *yaml.Node, node *yaml.Node) error {
+	old.Style &^= yaml.TaggedStyle
+	if node.Kind == yaml.ScalarNode && (old.ShortTag() != strTag || node.ShortTag() != strTag) {
+		return replaceValue(old, node, Config{Style: ParsedStyle})
+	}
+	return replaceValue(old, node, Config{})
+}
+
+// formatValue change the style and tag of node
+// A quoted, literal or folded scalar is always a string, unless it has an explicit tag.
+// Tag is written explicitly when the value is not resolved to it implicitly.
+// Tags of scalar types on mapping and sequence, and "!!map" and "!!seq" on the other kind are errors.
+func formatValue(node *yaml.Node, style ValueStyle, tag string) error {
+	switch style {
+	case KeepStyle, ParsedStyle:
+	case FlowStyle:
+		if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+			node.Style |= yaml.FlowStyle
+		}
+	case BlockStyle:
+		node.Style &^= yaml.FlowStyle
+	default:
+		if node.Kind != yaml.ScalarNode {
+			break
+		}
+		node.Style = node.Style&yaml.TaggedStyle | yamlStyles[style]
+		if style != PlainStyle && node.Style&yaml.TaggedStyle == 0 {
+			node.Tag = strTag
+		}
+	}
+	if tag == "" {
+		return nil
+	}
+	node.Tag = tag
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		// a collection could not be a scalar, and a mapping could not be a sequence
+		switch short := node.ShortTag(); short {
+		case strTag, intTag, floatTag, boolTag, nullTag, timestampTag, "!!binary", mergeTag, mapTag, seqTag:
+			if short != implicitTag(node) {
+				return fmt.Errorf("cannot set %s value with tag %s", implicitTag(node), tag)
+			}
+		}
+		return nil
+	case yaml.AliasNode:
+		return fmt.Errorf("cannot set anchor reference '%s' with tag %s", node.Value, tag)
+	}
+	implicit := strTag
+	if node.Style&^yaml.TaggedStyle == 0 {
+		implicit = (&yaml.Node{Kind: yaml.ScalarNode, Value: node.Value}).ShortTag()
+	}
+	if tag != implicit && tag != strTag {
+		node.Style |= yaml.TaggedStyle
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return fmt.Errorf("cannot set value '%s' with tag %s: %s", node.Value, tag, err)
+	}
+	return nil
+}
+
+// replaceNode put node to the position recorded in parameter
+func (y *YQuery) replaceNode(parameter parseParameter, node *yaml.Node) {
+	if parameter.ParentNode == nil {
+		y.RootNode = node
+		return
+	}
+	parameter.ParentNode.Content[parameter.Index] = node
+}
+
+// maxPadIndex is the largest index PadSequence fills sequence to
+// It prevents a typo of index, or an index from untrusted data, from building a huge sequence.
+const maxPadIndex = 10000
+
+// padSequence fill sequence with null until it has index items
+func padSequence(node *yaml.Node, index int, pad bool) error {
+	if index > len(node.Content) && !pad {
+		return fmt.Errorf("index %d is out of range while it only has %d item, set PadSequence to fill it", index, len(node.Content))
+	}
+	if index > len(node.Content) && index > maxPadIndex {
+		return fmt.Errorf("index %d is larger than %d, which PadSequence could fill to", index, maxPadIndex)
+	}
+	for len(node.Content) < index {
+		node.Content = append(node.Content, &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   nullTag,
+			Value: "null",
+		})
+	}
+	return nil
+}
+
+// newContainerNode create an empty sequence node if slice is an index, or an empty mapping node
+func newContainerNode(slice string) *yaml.Node {
+	if _, err := getSequenceNum(slice); err == nil {
+		return &yaml.Node{
+			Kind: yaml.SequenceNode,
+			Tag:  seqTag,
+		}
+	}
+	return &yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  mapTag,
+	}
+}
@@ -0,0 +1,181 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var setData = `
+empty:
+scalar: this is a scalar
+list:
+  - item 0
+emptyMap: {}
+`
+
+func TestRecursiveSetCreateNode(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(setData))
+	testCases := []casePair{
+		{"a.b[0].c.d", "new value"},
+		{"empty.b[0][0]", "in empty"},
+		{"scalar.b.c", "in scalar"},
+		{"list[1].c", "new item"},
+		{"emptyMap.b[0]", "in empty map"},
+	}
+	for _, c := range testCases {
+		err := yq.Set(c.Parser, c.Value, yquery.Config{Recursive: true})
+		asserts.NoError(err, c.Parser)
+		res, err := yq.Get(c.Parser)
+		asserts.NoError(err, c.Parser)
+		asserts.Equal(c.Value, res, c.Parser)
+	}
+	res, _ := yq.Get("list[0]")
+	asserts.Equal("item 0", res)
+}
+
+func TestRecursiveSetShouldError(t *testing.T) {
+	asserts := assert.New(t)
+	testCases := []struct {
+		Parser string
+		Config yquery.Config
+	}{
+		{"a.b.c", yquery.Config{}},
+		{"scalar.b.c", yquery.Config{}},
+		{"list[2].c", yquery.Config{Recursive: true}},
+		{"a.b[1].c", yquery.Config{Recursive: true}},
+		{"a.b.c[1]", yquery.Config{Recursive: true}},
+		{"a.b.[0]", yquery.Config{Recursive: true}},
+		{"scalar.b[0].c.d..e", yquery.Config{Recursive: true}},
+	}
+	for _, c := range testCases {
+		yq, _ := yquery.Unmarshal([]byte(setData))
+		err := yq.Set(c.Parser, "value", c.Config)
+		asserts.Error(err, c.Parser)
+		// document is not changed when failed
+		expected, _ := yquery.Unmarshal([]byte(setData))
+		asserts.Equal(expected.RootNode, yq.RootNode, c.Parser)
+	}
+}
+
+func TestSetPadSequence(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(setData))
+	asserts.NoError(yq.Set("list[2]", "item 2", yquery.Config{PadSequence: true}))
+	asserts.NoError(yq.Set("a.b[2].c", "c", yquery.Config{Recursive: true, PadSequence: true}))
+	testCases := []casePair{
+		{"list[0]", "item 0"},
+		{"list[1]", "null"},
+		{"list[2]", "item 2"},
+		{"a.b[0]", "null"},
+		{"a.b[2].c", "c"},
+	}
+	for _, c := range testCases {
+		res, err := yq.Get(c.Parser)
+		asserts.NoError(err, c.Parser)
+		asserts.Equal(c.Value, res, c.Parser)
+	}
+	out, _ := yq.Marshal()
+	asserts.Contains(string(out), "- null\n")
+
+	asserts.Error(yq.Set("list[99999999]", "huge", yquery.Config{PadSequence: true}))
+	asserts.Error(yq.Set("a.d[10001]", "huge", yquery.Config{Recursive: true, PadSequence: true}))
+	asserts.NoError(yq.Set("a.d[10000]", "last", yquery.Config{Recursive: true, PadSequence: true}))
+}
+
+// language=yaml
+var styleData = `# head of quoted
+quoted: "quoted value" # line of quoted
+single: 'single value'
+tagged: !!str 0123
+literal: |
+  line 1
+  line 2
+anchor: &anchor plain
+alias: *anchor
+`
+
+func TestSetKeepStyle(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(styleData))
+	asserts.NoError(yq.Set("quoted", "123"))
+	asserts.NoError(yq.Set("single", "yes"))
+	asserts.NoError(yq.Set("tagged", "0456"))
+	asserts.NoError(yq.Set("literal", "line 3"))
+	asserts.NoError(yq.Set("anchor", "new plain"))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal(`# head of quoted
+quoted: "123" # line of quoted
+single: 'yes'
+tagged: !!str 0456
+literal: |-
+    line 3
+anchor: &anchor new plain
+alias: *anchor
+`, string(out))
+	res, _ := yq.Get("alias")
+	asserts.Equal("new plain", res)
+}
+
+func TestSetOverrideStyle(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(styleData))
+	asserts.NoError(yq.Set("quoted", "plain value", yquery.Config{Style: yquery.PlainStyle}))
+	asserts.NoError(yq.Set("single", "123", yquery.Config{Style: yquery.ParsedStyle}))
+	asserts.NoError(yq.Set("newItem", "007", yquery.Config{Style: yquery.DoubleQuotedStyle}))
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Contains(string(out), "quoted: plain value # line of quoted\n")
+	asserts.Contains(string(out), "single: 123\n")
+	asserts.Contains(string(out), "newItem: \"007\"\n")
+}
+
+func TestSetWithTag(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(styleData))
+	testCases := []struct {
+		Parser   string
+		Value    string
+		Config   yquery.Config
+		Expected string
+	}{
+		{"version", "0123", yquery.Config{Tag: "!!str"}, "version: \"0123\"\n"},
+		{"enabled", "yes", yquery.Config{Tag: "!!str", Style: yquery.SingleQuotedStyle}, "enabled: 'yes'\n"},
+		{"port", "8080", yquery.Config{Tag: "!!int", Style: yquery.DoubleQuotedStyle}, "port: !!int \"8080\"\n"},
+		{"custom", "value", yquery.Config{Tag: "!foo"}, "custom: !foo value\n"},
+		{"script", "echo hello", yquery.Config{Style: yquery.LiteralStyle}, "script: |-\n    echo hello\n"},
+		{"folded", "folded text", yquery.Config{Style: yquery.FoldedStyle}, "folded: >-\n    folded text\n"},
+		{"flowMap", "a: 1", yquery.Config{Style: yquery.FlowStyle}, "flowMap: {a: 1}\n"},
+		{"flowList", "[1, 2]", yquery.Config{Style: yquery.BlockStyle}, "flowList:\n    - 1\n    - 2\n"},
+	}
+	for _, c := range testCases {
+		err := yq.Set(c.Parser, c.Value, c.Config)
+		asserts.NoError(err, c.Parser)
+		out, _ := yq.Marshal()
+		asserts.Contains(string(out), c.Expected, c.Parser)
+	}
+	res, _ := yq.Get("version")
+	asserts.Equal("0123", res)
+
+	asserts.Error(yq.Set("port", "abc", yquery.Config{Tag: "!!int"}))
+	res, _ = yq.Get("port")
+	asserts.Equal("8080", res)
+
+	for _, c := range []struct {
+		Value string
+		Tag   string
+	}{{"[1, 2]", "!!str"}, {"{a: 1}", "!!int"}, {"[1]", "!!map"}, {"{a: 1}", "!!seq"}, {"[1]", "tag:yaml.org,2002:null"}} {
+		asserts.Error(yq.Set("flowList", c.Value, yquery.Config{Tag: c.Tag}), c.Tag)
+	}
+	out, _ := yq.Marshal()
+	asserts.Contains(string(out), "flowList:\n    - 1\n    - 2\n")
+	asserts.NoError(yq.Set("flowList", "[1]", yquery.Config{Tag: "!!seq"}))
+	asserts.NoError(yq.Set("flowMap", "{a: 1}", yquery.Config{Tag: "!custom"}))
+	out, _ = yq.Marshal()
+	asserts.Contains(string(out), "flowMap: !custom {a: 1}\n")
+}
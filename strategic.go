@@ -0,0 +1,271 @@
+package yquery
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	patchDirective = "$patch"
+	patchDelete    = "delete"
+	patchReplace   = "replace"
+	patchMerge     = "merge"
+)
+
+// StrategicMergeConfig is the optional parameter for StrategicMerge
+type StrategicMergeConfig struct {
+	// Delimiter is the custom delimiter used in keys of MergeKeys
+	Delimiter string
+	// MergeKeys declares the key to merge sequence items of a path
+	// Key of map is the path of the sequence without index, e.g. "spec.template.spec.containers".
+	// A path matches if it is equal to, or ends with the key, e.g. "env" matches "spec.containers[0].env".
+	// The longest match is used.
+	//     MergeKeys: map[string]string{
+	//         "spec.template.spec.containers": "name",
+	//         "env":                           "name",
+	//     }
+	//
+	// Sequences without merge key are replaced by the one in patch.
+	MergeKeys map[string]string
+}
+
+// StrategicMerge merge patch into the document, like the strategic merge patch of Kubernetes
+// Mappings are merged recursively, and null in patch deletes the item.
+// Sequences are merged by the merge key declared in config, items with the same key are merged,
+// and others are appended. Sequences without merge key are replaced.
+// Directive "$patch" in a mapping of patch changes the behavior:
+//
+//	$patch: delete   # delete the mapping, or the sequence item with the same merge key
+//	$patch: replace  # replace the mapping instead of merge
+//
+// A sequence in patch with an item `{$patch: replace}` replaces the original sequence with its other items.
+// Comments and styles of items not replaced are kept. Anchor reference in the document could not be modified,
+// item comes from merge key is copied to the mapping before merge, and could not be deleted.
+// Patch values keep their own types, the quote style of a replaced scalar is kept only when both are strings.
+// It applies the whole patch or nothing.
+func (y *YQuery) StrategicMerge(patch *YQuery, config ...StrategicMergeConfig) error {
+	if len(config) > 1 {
+		return fmt.Errorf("strategic merge could only get 0 or 1 config, got %d", len(config))
+	}
+	m := strategicMerger{delimiter: "."}
+	if len(config) == 1 {
+		m.mergeKeys = config[0].MergeKeys
+		if config[0].Delimiter != "" {
+			m.delimiter = config[0].Delimiter
+		}
+	}
+	return y.modify(Operation{Name: "StrategicMerge"}, func() error {
+		patchNode, err := explodeNode(patch.RootNode, nil)
+		if err != nil {
+			return err
+		}
+		c := y.clone()
+		if patchNode == nil {
+			return nil
+		}
+		if c.RootNode == nil {
+			c.RootNode = &yaml.Node{Kind: patchNode.Kind, Tag: patchNode.Tag}
+		}
+		merged, err := m.merge(nil, c.RootNode, patchNode)
+		if err != nil {
+			return err
+		}
+		if merged == nil {
+			return fmt.Errorf("cannot delete the whole document")
+		}
+		if merged != c.RootNode {
+			if err := replaceTypedValue(c.RootNode, merged); err != nil {
+				return err
+			}
+		}
+		y.restore(c)
+		return nil
+	})
+}
+
+type strategicMerger struct {
+	delimiter string
+	mergeKeys map[string]string
+}
+
+// merge return the merged node of original and patch, nil means it should be deleted
+// Original is modified in place when it is merged, a different node is returned when it should be replaced.
+func (m *strategicMerger) merge(path []string, original *yaml.Node, patch *yaml.Node) (*yaml.Node, error) {
+	if original.Alias != nil {
+		return nil, fmt.Errorf("the item '%s' reaches an anchor reference. You can not modify value from anchor reference", strings.Join(path, m.delimiter))
+	}
+	switch patch.Kind {
+	case yaml.MappingNode:
+		switch directive := mappingValue(patch, patchDirective); {
+		case directive == nil || directive.Value == patchMerge:
+		case directive.Value == patchDelete:
+			return nil, nil
+		case directive.Value == patchReplace:
+			return cleanPatch(patch), nil
+		default:
+			return nil, fmt.Errorf("unknown directive '%s: %s' in '%s'", patchDirective, directive.Value, strings.Join(path, m.delimiter))
+		}
+		if original.Kind != yaml.MappingNode {
+			return cleanPatch(patch), nil
+		}
+		return original, m.mergeMapping(path, original, patch)
+	case yaml.SequenceNode:
+		for _, item := range patch.Content {
+			if directive := mappingValue(item, patchDirective); directive != nil && directive.Value == patchReplace {
+				return cleanPatch(patch), nil
+			}
+		}
+		mergeKey := m.mergeKey(path)
+		if mergeKey == "" || original.Kind != yaml.SequenceNode {
+			return cleanPatch(patch), nil
+		}
+		return original, m.mergeSequence(path, original, patch, mergeKey)
+	default:
+		return copyNode(patch), nil
+	}
+}
+
+func (m *strategicMerger) mergeMapping(path []string, original *yaml.Node, patch *yaml.Node) error {
+	for i := 0; i+1 < len(patch.Content); i += 2 {
+		key, value := patch.Content[i], patch.Content[i+1]
+		if key.Value == patchDirective {
+			continue
+		}
+		keyPath := append(path, key.Value)
+		index := -1
+		for j := 0; j+1 < len(original.Content); j += 2 {
+			if original.Content[j].Tag != mergeTag && original.Content[j].Value == key.Value {
+				index = j + 1
+			}
+		}
+		if index < 0 {
+			inherited := findInMerge(original, key.Value)
+			if inherited != nil && (value.ShortTag() == nullTag || isDeletePatch(value)) {
+				return fmt.Errorf("the item '%s' comes from a merge key, it cannot be deleted", strings.Join(keyPath, m.delimiter))
+			}
+			if value.ShortTag() == nullTag || isDeletePatch(value) {
+				// nothing to delete
+				continue
+			}
+			var merged *yaml.Node
+			if inherited != nil {
+				var err error
+				if merged, err = m.merge(keyPath, materializeMergeItem(inherited), value); err != nil {
+					return err
+				}
+			} else {
+				merged = cleanPatch(value)
+			}
+			if merged != nil {
+				original.Content = append(original.Content, copyNode(key), merged)
+			}
+			continue
+		}
+		if value.ShortTag() == nullTag {
+			removeContent(original, index)
+			continue
+		}
+		merged, err := m.merge(keyPath, original.Content[index], value)
+		if err != nil {
+			return err
+		}
+		if merged == nil {
+			removeContent(original, index)
+		} else if merged != original.Content[index] {
+			if err := replaceTypedValue(original.Content[index], merged); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *strategicMerger) mergeSequence(path []string, original *yaml.Node, patch *yaml.Node, mergeKey string) error {
+	for _, item := range patch.Content {
+		keyNode := mappingValue(item, mergeKey)
+		if item.Kind != yaml.MappingNode || keyNode == nil {
+			return fmt.Errorf("item of '%s' in patch has no merge key '%s'", strings.Join(path, m.delimiter), mergeKey)
+		}
+		index := -1
+		for i, content := range original.Content {
+			if value := mappingValue(resolveAlias(content), mergeKey); value != nil && value.Value == keyNode.Value {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			if !isDeletePatch(item) {
+				original.Content = append(original.Content, cleanPatch(item))
+			}
+			continue
+		}
+		merged, err := m.merge(path, original.Content[index], item)
+		if err != nil {
+			return err
+		}
+		if merged == nil {
+			removeContent(original, index)
+		} else if merged != original.Content[index] {
+			if err := replaceTypedValue(original.Content[index], merged); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergeKey return the merge key declared for path
+func (m *strategicMerger) mergeKey(path []string) string {
+	mergeKey := ""
+	matched := -1
+	for pattern, key := range m.mergeKeys {
+		patternSlices := strings.Split(pattern, m.delimiter)
+		if len(patternSlices) > len(path) || len(patternSlices) <= matched {
+			continue
+		}
+		if strings.Join(path[len(path)-len(patternSlices):], m.delimiter) == pattern {
+			mergeKey = key
+			matched = len(patternSlices)
+		}
+	}
+	return mergeKey
+}
+
+// isDeletePatch return true if node is a mapping with "$patch: delete"
+func isDeletePatch(node *yaml.Node) bool {
+	directive := mappingValue(node, patchDirective)
+	return node.Kind == yaml.MappingNode && directive != nil && directive.Value == patchDelete
+}
+
+// cleanPatch return a copy of patch without directives and null items, which could be put into document directly
+func cleanPatch(patch *yaml.Node) *yaml.Node {
+	node := copyNode(patch)
+	walkNode(node, func(n *yaml.Node) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			content := make([]*yaml.Node, 0, len(n.Content))
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == patchDirective || n.Content[i+1].ShortTag() == nullTag || isDeletePatch(n.Content[i+1]) {
+					continue
+				}
+				content = append(content, n.Content[i], n.Content[i+1])
+			}
+			n.Content = content
+		case yaml.SequenceNode:
+			content := make([]*yaml.Node, 0, len(n.Content))
+			for _, item := range n.Content {
+				if directive := mappingValue(item, patchDirective); directive != nil && len(item.Content) == 2 {
+					// directive only item
+					continue
+				}
+				if !isDeletePatch(item) {
+					content = append(content, item)
+				}
+			}
+			n.Content = content
+		}
+	})
+	return node
+}
@@ -0,0 +1,177 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=yaml
+var deploymentData = `spec:
+  replicas: 1 # line of replicas
+  template:
+    metadata:
+      labels:
+        app: web
+        tier: front
+    spec:
+      containers:
+        - name: web
+          image: web:1.0
+          env:
+            - name: MODE
+              value: dev
+            - name: DEBUG
+              value: "true"
+        - name: sidecar
+          image: sidecar:1.0
+      volumes:
+        - name: data
+        - name: cache
+`
+
+var deploymentMergeKeys = yquery.StrategicMergeConfig{
+	MergeKeys: map[string]string{
+		"spec.template.spec.containers": "name",
+		"env":                           "name",
+	},
+}
+
+func TestStrategicMerge(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(deploymentData))
+	patch, _ := yquery.Unmarshal([]byte(`spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        tier: null
+        version: v2
+    spec:
+      containers:
+        - name: web
+          image: web:2.0
+          env:
+            - name: MODE
+              value: prod
+            - name: DEBUG
+              $patch: delete
+            - name: NEW
+              value: "1"
+        - name: sidecar
+          $patch: delete
+        - name: logger
+          image: logger:1.0
+      volumes:
+        - name: data
+`))
+	asserts.NoError(yq.StrategicMerge(patch, deploymentMergeKeys))
+	testCases := []casePair{
+		{"spec.replicas", "3"},
+		{"spec.template.metadata.labels.app", "web"},
+		{"spec.template.metadata.labels.version", "v2"},
+		{"spec.template.spec.containers[0].image", "web:2.0"},
+		{"spec.template.spec.containers[0].env[0].value", "prod"},
+		{"spec.template.spec.containers[0].env[1].name", "NEW"},
+		{"spec.template.spec.containers[1].name", "logger"},
+		{"spec.template.spec.volumes[0].name", "data"},
+	}
+	for _, c := range testCases {
+		v, err := yq.Get(c.Parser)
+		asserts.NoError(err, c.Parser)
+		asserts.Equal(c.Value, v, c.Parser)
+	}
+	for _, parser := range []string{
+		"spec.template.metadata.labels.tier",
+		"spec.template.spec.containers[0].env[2]",
+		"spec.template.spec.containers[2]",
+		"spec.template.spec.volumes[1]",
+	} {
+		_, err := yq.Get(parser)
+		asserts.Error(err, parser)
+	}
+	comment, err := yq.GetComment("spec.replicas")
+	asserts.NoError(err)
+	asserts.Equal("line of replicas", comment.Line)
+}
+
+func TestStrategicMergeDirectives(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(deploymentData))
+	patch, _ := yquery.Unmarshal([]byte(`spec:
+  template:
+    metadata:
+      labels:
+        $patch: replace
+        app: api
+    spec:
+      containers:
+        - $patch: replace
+        - name: api
+          image: api:1.0
+`))
+	asserts.NoError(yq.StrategicMerge(patch, deploymentMergeKeys))
+	labels, _ := yq.GetRaw("spec.template.metadata.labels")
+	asserts.Equal("app: api", labels)
+	containers, _ := yq.GetRaw("spec.template.spec.containers")
+	asserts.Equal("- name: api\n  image: api:1.0", containers)
+}
+
+func TestStrategicMergeAnchor(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(`base: &base
+  a: 1
+  b: 2
+merged:
+  <<: *base
+  c: 3
+alias: *base
+`))
+	patch, _ := yquery.Unmarshal([]byte("merged:\n  a: 10\n"))
+	asserts.NoError(yq.StrategicMerge(patch))
+	v, _ := yq.Get("merged.a")
+	asserts.Equal("10", v)
+	v, _ = yq.Get("base.a")
+	asserts.Equal("1", v)
+
+	before, _ := yq.Marshal()
+	for _, p := range []string{
+		"merged:\n  c: 4\nalias:\n  a: 10\n",
+		"merged:\n  b: null\n",
+		"merged:\n  b:\n    $patch: delete\n",
+	} {
+		patch, _ = yquery.Unmarshal([]byte(p))
+		asserts.Error(yq.StrategicMerge(patch), p)
+		after, _ := yq.Marshal()
+		asserts.Equal(string(before), string(after))
+	}
+}
+
+func TestStrategicMergeValueType(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte("a: \"1\" # quoted\nb: !!str 2\nc: 'string'\nd: [1, 2]\n"))
+	patch, _ := yquery.Unmarshal([]byte("a: 1\nb: true\nc: new\nd:\n  - 3\n"))
+	asserts.NoError(yq.StrategicMerge(patch))
+	out, _ := yq.Marshal()
+	asserts.Equal("a: 1 # quoted\nb: true\nc: 'new'\nd: [3]\n", string(out))
+}
+
+func TestStrategicMergeError(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(deploymentData))
+	before, _ := yq.Marshal()
+	patch, _ := yquery.Unmarshal([]byte(`spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - image: no-name
+`))
+	asserts.Error(yq.StrategicMerge(patch, deploymentMergeKeys))
+	patch, _ = yquery.Unmarshal([]byte("spec:\n  $patch: unknown\n"))
+	asserts.Error(yq.StrategicMerge(patch))
+	after, _ := yq.Marshal()
+	asserts.Equal(string(before), string(after))
+}
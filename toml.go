@@ -0,0 +1,455 @@
+package yquery
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// bareTOMLKey matches keys written without quote in TOML
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// TOMLIssue is an item which could not be represented in TOML as it is
+type TOMLIssue struct {
+	// Path is the parser string of the item, which could be used in Get
+	Path string
+	// Reason tells what is lost, and how the item is written
+	Reason string
+}
+
+// TOMLIssues is a list of TOMLIssue, in the order of the document
+type TOMLIssues []TOMLIssue
+
+// UnmarshalTOML parse TOML data into a document
+// Tables and inline tables become mappings, arrays and arrays of tables become sequences, keys keep their order.
+// Local dates and datetimes become timestamps, e.g. "1979-05-27" and "1979-05-27 07:32:00", which are written back
+// as local values by MarshalTOML. Local times become strings. Comments are not kept.
+func UnmarshalTOML(in []byte) (*YQuery, error) {
+	var data map[string]interface{}
+	metadata, err := toml.Decode(string(in), &data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid toml: %s", err)
+	}
+	order := map[string]int{}
+	for i, key := range metadata.Keys() {
+		if _, ok := order[key.String()]; !ok {
+			order[key.String()] = i
+		}
+	}
+	return &YQuery{
+		RootNode: tomlValueNode(data, nil, order),
+		document: yaml.Node{Kind: yaml.DocumentNode},
+	}, nil
+}
+
+// tomlValueNode convert value decoded from TOML to node, key is the TOML key of value
+// order is the position of each key in TOML data, keys of a table are sorted by it.
+func tomlValueNode(value interface{}, key toml.Key, order map[string]int) *yaml.Node {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		position := func(k string) int {
+			if i, ok := order[append(append(toml.Key{}, key...), k).String()]; ok {
+				return i
+			}
+			return len(order)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if position(keys[i]) != position(keys[j]) {
+				return position(keys[i]) < position(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag}
+		for _, k := range keys {
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: strTag, Value: k}
+			node.Content = append(node.Content, keyNode, tomlValueNode(v[k], append(append(toml.Key{}, key...), k), order))
+		}
+		return node
+	case []map[string]interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: seqTag}
+		for _, item := range v {
+			node.Content = append(node.Content, tomlValueNode(item, key, order))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: seqTag}
+		for _, item := range v {
+			node.Content = append(node.Content, tomlValueNode(item, key, order))
+		}
+		return node
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: boolTag, Value: strconv.FormatBool(v)}
+	case int64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: intTag, Value: strconv.FormatInt(v, 10)}
+	case float64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: floatTag, Value: formatFloat(v)}
+	case time.Time:
+		// the parser marks local values with these locations
+		switch v.Location().String() {
+		case "date-local":
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: timestampTag, Value: v.Format("2006-01-02")}
+		case "time-local":
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: strTag, Value: v.Format("15:04:05.999999999")}
+		case "datetime-local":
+			// the form of timestamp without time zone in yaml
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: timestampTag, Value: v.Format(localTimestampLayout)}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: timestampTag, Value: v.Format(time.RFC3339Nano)}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: strTag, Value: fmt.Sprint(v)}
+	}
+}
+
+// localTimestampLayout is the layout of timestamp without time zone, go-yaml reads it as UTC
+const localTimestampLayout = "2006-01-02 15:04:05.999999999"
+
+// isLocalTimestamp return true if timestamp has time but no time zone, e.g. "1979-05-27 07:32:00"
+func isLocalTimestamp(timestamp string) bool {
+	return len(timestamp) > len("2006-01-02") && !strings.ContainsAny(timestamp[len("2006-01-02"):], "Zz+-")
+}
+
+// formatFloat write f as yaml float, which is not resolved as int
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return ".nan"
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// MarshalTOML marshal the document as TOML, return items which could not be represented in TOML as issues
+//     out, issues, err := yq.MarshalTOML()
+//
+// The root must be a mapping. Mappings become tables, sequences of mappings become arrays of tables,
+// other mappings and sequences inside arrays become inline tables and arrays.
+// Items of a table are written before its sub-tables, otherwise keys keep their order.
+// Head comments and line comments are kept, except those inside arrays and inline tables.
+//
+// Items are still written when they are reported:
+// anchor references and merge keys are resolved to copies, null items are omitted,
+// arrays mixing types are written as they are but are only valid since TOML 1.0,
+// binary data is written as base64 string, custom tags are dropped,
+// and scalars which could not be read as their tags, e.g. `!!float abc`, are written as strings.
+func (y *YQuery) MarshalTOML() ([]byte, TOMLIssues, error) {
+	if y.RootNode == nil {
+		return nil, nil, nil
+	}
+	if resolveAlias(y.RootNode).Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("the root of TOML document must be a mapping, got %s", resolveAlias(y.RootNode).ShortTag())
+	}
+	w := tomlWriter{}
+	w.findReferences("", y.RootNode)
+	node, err := explodeNode(y.RootNode, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	w.comment(y.document.HeadComment)
+	if w.buffer.Len() > 0 {
+		w.buffer.WriteString("\n")
+	}
+	w.table(nil, "", node)
+	if y.document.FootComment != "" {
+		w.buffer.WriteString("\n")
+		w.comment(y.document.FootComment)
+	}
+	return w.buffer.Bytes(), w.issues, nil
+}
+
+type tomlWriter struct {
+	buffer bytes.Buffer
+	issues TOMLIssues
+}
+
+func (w *tomlWriter) issue(path string, format string, a ...interface{}) {
+	w.issues = append(w.issues, TOMLIssue{Path: path, Reason: fmt.Sprintf(format, a...)})
+}
+
+// findReferences report anchor references and merge keys in node
+func (w *tomlWriter) findReferences(path string, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		w.issue(path, "anchor reference to '%s' is written as a copy", node.Value)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Tag == mergeTag {
+				w.issue(path, "merge key is resolved to the merged items")
+				continue
+			}
+			w.findReferences(joinPath(path, node.Content[i].Value), node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			w.findReferences(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	}
+}
+
+// table write items of mapping, keys is the TOML key of the table
+func (w *tomlWriter) table(keys []string, path string, mapping *yaml.Node) {
+	w.checkTag(path, mapping)
+	var tables []int
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		itemPath := joinPath(path, key.Value)
+		if !w.validItem(itemPath, key, value) {
+			continue
+		}
+		if isTOMLTable(value) || isTOMLTableArray(value) {
+			tables = append(tables, i)
+			continue
+		}
+		w.comment(key.HeadComment)
+		w.buffer.WriteString(tomlKey(key.Value) + " = " + w.inline(itemPath, value))
+		w.lineComment(key, value)
+	}
+	for _, i := range tables {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		itemPath := joinPath(path, key.Value)
+		tableKeys := append(append([]string{}, keys...), tomlKey(key.Value))
+		if value.Kind == yaml.MappingNode {
+			w.header(key, value, "["+strings.Join(tableKeys, ".")+"]")
+			w.table(tableKeys, itemPath, value)
+			continue
+		}
+		w.checkTag(itemPath, value)
+		for j, item := range value.Content {
+			w.header(key, item, "[["+strings.Join(tableKeys, ".")+"]]")
+			w.table(tableKeys, fmt.Sprintf("%s[%d]", itemPath, j), item)
+		}
+	}
+}
+
+// header write the header of a table with comments of its key
+func (w *tomlWriter) header(key *yaml.Node, value *yaml.Node, header string) {
+	if w.buffer.Len() > 0 {
+		w.buffer.WriteString("\n")
+	}
+	w.comment(key.HeadComment)
+	w.buffer.WriteString(header)
+	w.lineComment(key, value)
+}
+
+// validItem report and return false for items could not be written
+func (w *tomlWriter) validItem(path string, key *yaml.Node, value *yaml.Node) bool {
+	if key.Kind != yaml.ScalarNode {
+		w.issue(path, "key of %s is omitted, TOML only accepts string keys", key.ShortTag())
+		return false
+	}
+	if value.ShortTag() == nullTag {
+		w.issue(path, "null is omitted")
+		return false
+	}
+	return true
+}
+
+// inline write node as an inline value
+func (w *tomlWriter) inline(path string, node *yaml.Node) string {
+	w.checkTag(path, node)
+	var items []string
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			itemPath := joinPath(path, key.Value)
+			if w.validItem(itemPath, key, value) {
+				items = append(items, tomlKey(key.Value)+" = "+w.inline(itemPath, value))
+			}
+		}
+		if len(items) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(items, ", ") + " }"
+	case yaml.SequenceNode:
+		types := map[string]bool{}
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if item.ShortTag() == nullTag {
+				w.issue(itemPath, "null is omitted")
+				continue
+			}
+			types[item.ShortTag()] = true
+			items = append(items, w.inline(itemPath, item))
+		}
+		if len(types) > 1 {
+			w.issue(path, "array mixing types is only valid since TOML 1.0")
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return w.scalar(path, node)
+}
+
+// scalar write scalar node as TOML value
+// Scalars which could not be written as their types are written as strings, and reported.
+func (w *tomlWriter) scalar(path string, node *yaml.Node) string {
+	var value interface{}
+	tag := node.ShortTag()
+	switch tag {
+	case strTag:
+		return tomlString(node.Value)
+	case intTag:
+		if err := node.Decode(&value); err == nil {
+			if i, ok := value.(int); ok {
+				return strconv.Itoa(i)
+			}
+		}
+		w.issue(path, "integer %s is out of TOML range, it is written as string", node.Value)
+		return tomlString(node.Value)
+	case floatTag:
+		if err := node.Decode(&value); err == nil {
+			if f, ok := value.(float64); ok {
+				return tomlFloat(node.Value, f)
+			}
+		}
+	case boolTag:
+		if err := node.Decode(&value); err == nil {
+			if b, ok := value.(bool); ok {
+				return strconv.FormatBool(b)
+			}
+		}
+	case timestampTag:
+		if err := node.Decode(&value); err == nil {
+			if t, ok := value.(time.Time); ok {
+				switch {
+				case len(node.Value) == len("2006-01-02"):
+					return node.Value
+				case isLocalTimestamp(node.Value):
+					return t.Format("2006-01-02T15:04:05.999999999")
+				}
+				return t.Format(time.RFC3339Nano)
+			}
+		}
+	case "!!binary":
+		w.issue(path, "binary data is written as base64 string")
+		return tomlString(node.Value)
+	}
+	if strings.HasPrefix(tag, "!!") {
+		// custom tags are reported by checkTag
+		w.issue(path, "%s %s could not be read, it is written as string", tag, node.Value)
+	}
+	return tomlString(node.Value)
+}
+
+// checkTag report custom tag of node
+func (w *tomlWriter) checkTag(path string, node *yaml.Node) {
+	tag := node.ShortTag()
+	if !strings.HasPrefix(tag, "!!") {
+		w.issue(path, "tag %s is dropped", tag)
+	}
+}
+
+// comment write comment lines
+func (w *tomlWriter) comment(comment string) {
+	for _, line := range strings.Split(comment, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			w.buffer.WriteString(line + "\n")
+		}
+	}
+}
+
+// lineComment write line comment of key or value and end the line
+func (w *tomlWriter) lineComment(key *yaml.Node, value *yaml.Node) {
+	for _, comment := range []string{key.LineComment, value.LineComment} {
+		if comment != "" {
+			w.buffer.WriteString(" " + comment)
+		}
+	}
+	w.buffer.WriteString("\n")
+}
+
+func isTOMLTable(node *yaml.Node) bool {
+	return node.Kind == yaml.MappingNode
+}
+
+// isTOMLTableArray return true for non-empty sequence of mappings
+func isTOMLTableArray(node *yaml.Node) bool {
+	if node.Kind != yaml.SequenceNode || len(node.Content) == 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlKey quote key if it is not a bare key
+func tomlKey(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString write s as TOML basic string
+func tomlString(s string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			builder.WriteString(`\` + string(r))
+		case '\b':
+			builder.WriteString(`\b`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\f':
+			builder.WriteString(`\f`)
+		case '\r':
+			builder.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&builder, `\u%04X`, r)
+			} else {
+				builder.WriteRune(r)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// tomlFloat write float f, text is used if it is valid in TOML
+func tomlFloat(text string, f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case jsonNumber.MatchString(text) && strings.ContainsAny(text, ".eE"):
+		return text
+	}
+	return strings.TrimPrefix(formatFloat(f), "+")
+}
+
+// joinPath return the parser string of key in the item of path
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,175 @@
+package yquery_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=toml
+var tomlData = `title = "example"
+ratio = 1.5
+created = 1979-05-27T07:32:00Z
+birthday = 1979-05-27
+alarm = 07:30:00
+
+[server]
+port = 8080
+hosts = ["a", "b"]
+limits = { cpu = 2, memory = "1G" }
+
+[[users]]
+name = "alice"
+admin = true
+
+[[users]]
+name = "bob"
+`
+
+func TestUnmarshalTOML(t *testing.T) {
+	asserts := assert.New(t)
+	yq, err := yquery.UnmarshalTOML([]byte(tomlData))
+	asserts.NoError(err)
+	res, err := yq.Get("users[1].name")
+	asserts.NoError(err)
+	asserts.Equal("bob", res)
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal(`title: example
+ratio: 1.5
+created: 1979-05-27T07:32:00Z
+birthday: 1979-05-27
+alarm: 07:30:00
+server:
+    port: 8080
+    hosts:
+        - a
+        - b
+    limits:
+        cpu: 2
+        memory: 1G
+users:
+    - name: alice
+      admin: true
+    - name: bob
+`, string(out))
+
+	out, issues, err := yq.MarshalTOML()
+	asserts.NoError(err)
+	asserts.Len(issues, 0)
+	asserts.Equal(`title = "example"
+ratio = 1.5
+created = 1979-05-27T07:32:00Z
+birthday = 1979-05-27
+alarm = "07:30:00"
+
+[server]
+port = 8080
+hosts = ["a", "b"]
+
+[server.limits]
+cpu = 2
+memory = "1G"
+
+[[users]]
+name = "alice"
+admin = true
+
+[[users]]
+name = "bob"
+`, string(out))
+
+	_, err = yquery.UnmarshalTOML([]byte("a = "))
+	asserts.Error(err)
+}
+
+// language=yaml
+var tomlYamlData = `# head of document
+
+# name of service
+name: web # line comment
+base: &base
+  image: nginx
+  "port number": 80
+service:
+  <<: *base
+  replicas: 2
+empty: null
+mixed: [1, "a", null]
+data: !!binary aGVsbG8=
+`
+
+func TestMarshalTOML(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(tomlYamlData))
+	out, issues, err := yq.MarshalTOML()
+	asserts.NoError(err)
+	asserts.Equal(`# head of document
+
+# name of service
+name = "web" # line comment
+mixed = [1, "a"]
+data = "aGVsbG8="
+
+[base]
+image = "nginx"
+"port number" = 80
+
+[service]
+image = "nginx"
+"port number" = 80
+replicas = 2
+`, string(out))
+	asserts.Equal(yquery.TOMLIssues{
+		{Path: "service", Reason: "merge key is resolved to the merged items"},
+		{Path: "empty", Reason: "null is omitted"},
+		{Path: "mixed[2]", Reason: "null is omitted"},
+		{Path: "mixed", Reason: "array mixing types is only valid since TOML 1.0"},
+		{Path: "data", Reason: "binary data is written as base64 string"},
+	}, issues)
+
+	back, err := yquery.UnmarshalTOML(out)
+	asserts.NoError(err)
+	res, err := back.Get("service/port number", "/")
+	asserts.NoError(err)
+	asserts.Equal("80", res)
+
+	scalar, _ := yquery.Unmarshal([]byte("- a\n"))
+	_, _, err = scalar.MarshalTOML()
+	asserts.Error(err)
+}
+
+func TestTOMLLocalDatetime(t *testing.T) {
+	asserts := assert.New(t)
+	yq, err := yquery.UnmarshalTOML([]byte("a = 1979-05-27T07:32:00\nb = 1979-05-27T07:32:00.5\n"))
+	asserts.NoError(err)
+	out, _ := yq.Marshal()
+	asserts.Equal("a: 1979-05-27 07:32:00\nb: 1979-05-27 07:32:00.5\n", string(out))
+	reloaded, err := yquery.Unmarshal(out)
+	asserts.NoError(err)
+	var value struct {
+		A time.Time `yaml:"a"`
+	}
+	asserts.NoError(reloaded.RootNode.Decode(&value))
+	asserts.Equal(7, value.A.Hour())
+	out, issues, err := reloaded.MarshalTOML()
+	asserts.NoError(err)
+	asserts.Empty(issues)
+	asserts.Equal("a = 1979-05-27T07:32:00\nb = 1979-05-27T07:32:00.5\n", string(out))
+}
+
+func TestMarshalTOMLStringFallback(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte("a: !!float abc\nb: !!bool maybe\nc: !!timestamp 1979-05-27T07:32:00\n"))
+	out, issues, err := yq.MarshalTOML()
+	asserts.NoError(err)
+	asserts.Equal("a = \"abc\"\nb = \"maybe\"\nc = \"1979-05-27T07:32:00\"\n", string(out))
+	asserts.Equal(yquery.TOMLIssues{
+		{Path: "a", Reason: "!!float abc could not be read, it is written as string"},
+		{Path: "b", Reason: "!!bool maybe could not be read, it is written as string"},
+		{Path: "c", Reason: "!!timestamp 1979-05-27T07:32:00 could not be read, it is written as string"},
+	}, issues)
+}
@@ -0,0 +1,106 @@
+package yquery
+
+import (
+	"fmt"
+)
+
+// errFinished is returned when a committed or rolled back transaction is modified
+var errFinished = fmt.Errorf("transaction has already been committed or rolled back")
+
+// errOriginModified is returned by Commit when the original document is modified after the transaction begins
+var errOriginModified = fmt.Errorf("the original document has been modified after the transaction began")
+
+// Tx is a transaction of YQuery
+// It holds a copy of the document, all Get, Set and Delete operations on it do not affect the original document
+// until Commit is called.
+type Tx struct {
+	*YQuery
+
+	origin *YQuery
+	done   bool
+	// originGeneration is the generation of origin when the transaction begins
+	originGeneration int
+}
+
+// Begin start a transaction
+// The transaction should be finished by Commit or Rollback.
+func (y *YQuery) Begin() *Tx {
+	tx := &Tx{
+		YQuery:           y.clone(),
+		origin:           y,
+		originGeneration: y.generation,
+	}
+	if y.history != nil {
+		// operations in transaction could be undone separately before commit
+		tx.history = &history{depth: y.history.depth, transaction: true}
+	}
+	return tx
+}
+
+// Commit apply all changes in the transaction to the original document
+// The original document is replaced by the one in transaction. If the original document is modified
+// by its methods after the transaction begins, e.g. Set or Undo, nothing is applied and an error is returned,
+// the transaction is finished as it is rolled back. Changes made to RootNode directly could not be detected.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return errFinished
+	}
+	tx.done = true
+	if tx.origin.generation != tx.originGeneration {
+		tx.finished = true
+		return errOriginModified
+	}
+	operation := Operation{Name: "Transaction"}
+	if tx.history != nil {
+		for _, o := range tx.history.operations {
+			operation.Paths = append(operation.Paths, o.Paths...)
+		}
+	}
+	err := tx.origin.modify(operation, func() error {
+		tx.origin.restore(tx.YQuery)
+		return nil
+	})
+	// the document is shared with the original one now
+	tx.finished = true
+	return err
+}
+
+// Rollback discard all changes in the transaction
+// The transaction could not be modified after Commit or Rollback.
+func (tx *Tx) Rollback() {
+	tx.done = true
+	tx.finished = true
+}
+
+// Transaction run fn in a transaction
+// Changes made in fn through tx are applied when fn returns nil,
+// or are discarded if fn returns an error or panics, and the document is left unchanged.
+//     err := yq.Transaction(func(tx *yquery.Tx) error {
+//         if err := tx.Set("a", "1"); err != nil {
+//             return err
+//         }
+//         return tx.Delete("b")
+//     })
+func (y *YQuery) Transaction(fn func(tx *Tx) error) error {
+	tx := y.Begin()
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// clone make a deep copy of y, history is not copied
+func (y *YQuery) clone() *YQuery {
+	c := *y
+	c.RootNode = cloneNode(y.RootNode)
+	c.history = nil
+	return &c
+}
+
+// restore set the state of y to s, history of y is kept
+func (y *YQuery) restore(s *YQuery) {
+	h := y.history
+	*y = *s
+	y.history = h
+}
@@ -0,0 +1,117 @@
+package yquery_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+func TestTransaction(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	err := yq.Transaction(func(tx *yquery.Tx) error {
+		if err := tx.Set("a", "new title"); err != nil {
+			return err
+		}
+		if err := tx.Set("c.d", "new d"); err != nil {
+			return err
+		}
+		res, _ := tx.Get("f.d")
+		asserts.Equal("new d", res)
+		// not applied before commit
+		res, _ = yq.Get("a")
+		asserts.Equal("title", res)
+		return tx.Delete("b")
+	})
+	asserts.NoError(err)
+	res, _ := yq.Get("a")
+	asserts.Equal("new title", res)
+	res, _ = yq.Get("f.d")
+	asserts.Equal("new d", res)
+	_, err = yq.Get("b")
+	asserts.Error(err)
+}
+
+func TestTransactionRollback(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	origin, _ := yq.Marshal()
+	err := yq.Transaction(func(tx *yquery.Tx) error {
+		if err := tx.Set("a", "new title"); err != nil {
+			return err
+		}
+		return tx.Set("f.d", "cannot set in anchor reference")
+	})
+	asserts.Error(err)
+	out, _ := yq.Marshal()
+	asserts.Equal(string(origin), string(out))
+
+	asserts.Panics(func() {
+		_ = yq.Transaction(func(tx *yquery.Tx) error {
+			_ = tx.Set("a", "new title")
+			panic(fmt.Errorf("unexpected"))
+		})
+	})
+	out, _ = yq.Marshal()
+	asserts.Equal(string(origin), string(out))
+
+	tx := yq.Begin()
+	asserts.NoError(tx.Set("a", "new title"))
+	tx.Rollback()
+	asserts.Error(tx.Commit())
+	res, _ := yq.Get("a")
+	asserts.Equal("title", res)
+}
+
+func TestTransactionFinished(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	tx := yq.Begin()
+	asserts.NoError(tx.Set("a", "new title"))
+	asserts.NoError(tx.Commit())
+	asserts.Error(tx.Set("a", "after commit"))
+	asserts.Error(tx.Delete("b"))
+	res, _ := yq.Get("a")
+	asserts.Equal("new title", res)
+	asserts.NoError(yq.Set("a", "origin is still writable"))
+
+	tx = yq.Begin()
+	tx.Rollback()
+	asserts.Error(tx.Set("a", "after rollback"))
+	_, err := tx.Undo()
+	asserts.Error(err)
+}
+
+func TestTransactionOriginModified(t *testing.T) {
+	asserts := assert.New(t)
+	yq, _ := yquery.Unmarshal([]byte(data))
+	tx := yq.Begin()
+	asserts.NoError(tx.Set("a", "new title"))
+	asserts.NoError(yq.Delete("b"))
+	asserts.Error(tx.Commit())
+	asserts.Error(tx.Set("a", "after failed commit"))
+	res, _ := yq.Get("a")
+	asserts.Equal("title", res)
+	_, err := yq.Get("b")
+	asserts.Error(err)
+
+	yq.SetHistoryDepth(2)
+	asserts.NoError(yq.Set("a", "second"))
+	tx = yq.Begin()
+	asserts.NoError(tx.Set("c.d", "new d"))
+	_, err = yq.Undo()
+	asserts.NoError(err)
+	asserts.Error(tx.Commit())
+
+	tx = yq.Begin()
+	asserts.NoError(tx.Set("c.d", "new d"))
+	asserts.NoError(tx.Commit())
+	tx = yq.Begin()
+	asserts.NoError(tx.Set("a", "third"))
+	asserts.NoError(tx.Commit())
+	res, _ = yq.Get("a")
+	asserts.Equal("third", res)
+}
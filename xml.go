@@ -0,0 +1,343 @@
+package yquery
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultAttributePrefix is the default AttributePrefix of XMLOptions
+	defaultAttributePrefix = "+@"
+	// defaultTextKey is the default TextKey of XMLOptions
+	defaultTextKey = "+content"
+	// defaultXMLIndent is the default Indent of XMLOptions
+	defaultXMLIndent = 2
+)
+
+// XMLOptions is the optional parameter for UnmarshalXML and MarshalXMLWith
+type XMLOptions struct {
+	// AttributePrefix is the prefix of keys for attributes, default is "+@"
+	//     <server name="web"/>
+	// is
+	//     server:
+	//       +@name: web
+	AttributePrefix string
+	// TextKey is the key of text in elements having attributes or child elements, default is "+content"
+	// Text of elements having neither of them is the value of the element.
+	TextKey string
+	// Sequences are paths of elements which are always sequences, e.g. "config.server"
+	// By default, only repeated elements become sequences.
+	Sequences []string
+	// Indent is the number of spaces of each indentation level of MarshalXMLWith, default is 2
+	Indent int
+}
+
+func getXMLOptions(options []XMLOptions) (XMLOptions, error) {
+	if len(options) > 1 {
+		return XMLOptions{}, fmt.Errorf("xml could only get 0 or 1 options, got %d", len(options))
+	}
+	o := XMLOptions{}
+	if len(options) == 1 {
+		o = options[0]
+	}
+	if o.AttributePrefix == "" {
+		o.AttributePrefix = defaultAttributePrefix
+	}
+	if o.TextKey == "" {
+		o.TextKey = defaultTextKey
+	}
+	if o.Indent < 0 {
+		return o, fmt.Errorf("indent could not be negative, got %d", o.Indent)
+	}
+	if o.Indent == 0 {
+		o.Indent = defaultXMLIndent
+	}
+	return o, nil
+}
+
+// UnmarshalXML parse XML data into a document, the root is a mapping with the root element as key
+//     <config version="2">
+//       <server>web</server>
+//       <server>db</server>
+//     </config>
+//
+// is
+//     config:
+//       +@version: 2
+//       server:
+//         - web
+//         - db
+//
+// so that Get("config.server[1]") returns "db". Types of text and attributes are inferred as yaml plain scalars,
+// and written back as they are. Comments, processing instructions and namespace prefixes are not kept.
+func UnmarshalXML(in []byte, options ...XMLOptions) (*YQuery, error) {
+	o, err := getXMLOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	sequences := map[string]bool{}
+	for _, path := range o.Sequences {
+		sequences[path] = true
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(in))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("invalid xml: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid xml: %s", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			u := xmlUnmarshaler{options: o, decoder: decoder, sequences: sequences}
+			value, err := u.element(start.Name.Local, start)
+			if err != nil {
+				return nil, fmt.Errorf("invalid xml: %s", err)
+			}
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: strTag, Value: start.Name.Local}
+			root := &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag, Content: []*yaml.Node{key, value}}
+			return &YQuery{RootNode: root, document: yaml.Node{Kind: yaml.DocumentNode}}, nil
+		}
+	}
+}
+
+type xmlUnmarshaler struct {
+	options   XMLOptions
+	decoder   *xml.Decoder
+	sequences map[string]bool
+}
+
+// element read the element of start until its end, path is the path of the element
+func (u *xmlUnmarshaler) element(path string, start xml.StartElement) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag}
+	for _, attr := range start.Attr {
+		node.Content = append(node.Content, u.key(u.options.AttributePrefix+xmlName(attr.Name)), xmlScalar(attr.Value))
+	}
+	var text strings.Builder
+	for {
+		token, err := u.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			childPath := joinPath(path, t.Name.Local)
+			child, err := u.element(childPath, t)
+			if err != nil {
+				return nil, err
+			}
+			u.addChild(node, t.Name.Local, child, u.sequences[childPath])
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(node.Content) == 0 {
+				return xmlScalar(content), nil
+			}
+			if content != "" {
+				node.Content = append(node.Content, u.key(u.options.TextKey), xmlScalar(content))
+			}
+			return node, nil
+		}
+	}
+}
+
+// addChild add child element to node, the value becomes a sequence when the element is repeated
+func (u *xmlUnmarshaler) addChild(node *yaml.Node, name string, child *yaml.Node, sequence bool) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != name {
+			continue
+		}
+		// elements are never sequences, so a sequence value is the repeated elements
+		existing := node.Content[i+1]
+		if existing.Kind != yaml.SequenceNode {
+			existing = &yaml.Node{Kind: yaml.SequenceNode, Tag: seqTag, Content: []*yaml.Node{existing}}
+			node.Content[i+1] = existing
+		}
+		existing.Content = append(existing.Content, child)
+		return
+	}
+	value := child
+	if sequence {
+		value = &yaml.Node{Kind: yaml.SequenceNode, Tag: seqTag, Content: []*yaml.Node{child}}
+	}
+	node.Content = append(node.Content, u.key(name), value)
+}
+
+func (u *xmlUnmarshaler) key(name string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: strTag, Value: name}
+}
+
+// xmlName return the name with "xmlns" prefix kept, other namespaces are dropped
+func xmlName(name xml.Name) string {
+	if name.Space == "xmlns" {
+		return "xmlns:" + name.Local
+	}
+	return name.Local
+}
+
+// xmlScalar return text as scalar node, tag is inferred as plain scalar
+func xmlScalar(text string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: text}
+	node.Tag = node.ShortTag()
+	return node
+}
+
+// MarshalXMLWith marshal the document as XML with options, see UnmarshalXML for conventions
+//     out, err := yq.MarshalXMLWith(yquery.XMLOptions{Indent: 4})
+//
+// The root must be a mapping with one key, which is the root element.
+// Sequences are written as repeated elements, null as empty elements. Anchor references and merge keys are resolved.
+// Keys which are not valid XML names, e.g. "a b" or "1a", are errors.
+func (y *YQuery) MarshalXMLWith(options XMLOptions) ([]byte, error) {
+	o, err := getXMLOptions([]XMLOptions{options})
+	if err != nil {
+		return nil, err
+	}
+	root, err := explodeNode(y.RootNode, nil)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil || root.Kind != yaml.MappingNode || len(root.Content) != 2 {
+		return nil, fmt.Errorf("the root of XML document must be a mapping with one key")
+	}
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buffer)
+	encoder.Indent("", strings.Repeat(" ", o.Indent))
+	m := xmlMarshaler{options: o, encoder: encoder}
+	if err := m.element(root.Content[0].Value, root.Content[0].Value, root.Content[1]); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	buffer.WriteString("\n")
+	return buffer.Bytes(), nil
+}
+
+type xmlMarshaler struct {
+	options XMLOptions
+	encoder *xml.Encoder
+}
+
+// element write node as elements of name, path is the path of node used in error message
+func (m *xmlMarshaler) element(path string, name string, node *yaml.Node) error {
+	if name == "" || strings.HasPrefix(name, m.options.AttributePrefix) || name == m.options.TextKey {
+		return fmt.Errorf("item '%s' could not be an element", path)
+	}
+	if !isXMLName(name) {
+		return fmt.Errorf("item '%s' could not be an element, '%s' is not a valid XML name", path, name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			if item.Kind == yaml.SequenceNode {
+				return fmt.Errorf("item '%s[%d]' is a sequence in sequence, which has no XML equivalent", path, i)
+			}
+			if err := m.element(fmt.Sprintf("%s[%d]", path, i), name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.MappingNode:
+		var children []int
+		text := ""
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			switch {
+			case key == m.options.TextKey:
+				if value.Kind != yaml.ScalarNode {
+					return fmt.Errorf("text '%s' must be a scalar", joinPath(path, key))
+				}
+				text = xmlText(value)
+			case strings.HasPrefix(key, m.options.AttributePrefix):
+				if value.Kind != yaml.ScalarNode {
+					return fmt.Errorf("attribute '%s' must be a scalar", joinPath(path, key))
+				}
+				attrName := strings.TrimPrefix(key, m.options.AttributePrefix)
+				if !isXMLName(attrName) {
+					return fmt.Errorf("attribute '%s' could not be written, '%s' is not a valid XML name", joinPath(path, key), attrName)
+				}
+				start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attrName}, Value: xmlText(value)})
+			default:
+				children = append(children, i)
+			}
+		}
+		if err := m.encoder.EncodeToken(start); err != nil {
+			return fmt.Errorf("cannot write item '%s': %s", path, err)
+		}
+		if text != "" {
+			if err := m.encoder.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+		}
+		for _, i := range children {
+			key := node.Content[i].Value
+			if err := m.element(joinPath(path, key), key, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		return m.encoder.EncodeToken(start.End())
+	}
+	if err := m.encoder.EncodeToken(start); err != nil {
+		return fmt.Errorf("cannot write item '%s': %s", path, err)
+	}
+	if text := xmlText(node); text != "" {
+		if err := m.encoder.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	return m.encoder.EncodeToken(start.End())
+}
+
+// isXMLName return true if name matches the Name production of XML 1.0
+func isXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !isXMLNameStartChar(r) && (i == 0 || !isXMLNameChar(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isXMLNameStartChar return true for NameStartChar of XML 1.0
+func isXMLNameStartChar(r rune) bool {
+	switch {
+	case r == ':', r == '_', 'A' <= r && r <= 'Z', 'a' <= r && r <= 'z':
+		return true
+	case 0xC0 <= r && r <= 0xD6, 0xD8 <= r && r <= 0xF6, 0xF8 <= r && r <= 0x2FF,
+		0x370 <= r && r <= 0x37D, 0x37F <= r && r <= 0x1FFF, 0x200C <= r && r <= 0x200D,
+		0x2070 <= r && r <= 0x218F, 0x2C00 <= r && r <= 0x2FEF, 0x3001 <= r && r <= 0xD7FF,
+		0xF900 <= r && r <= 0xFDCF, 0xFDF0 <= r && r <= 0xFFFD, 0x10000 <= r && r <= 0xEFFFF:
+		return true
+	}
+	return false
+}
+
+// isXMLNameChar return true for NameChar of XML 1.0 which is not NameStartChar
+func isXMLNameChar(r rune) bool {
+	switch {
+	case r == '-', r == '.', '0' <= r && r <= '9', r == 0xB7,
+		0x300 <= r && r <= 0x36F, 0x203F <= r && r <= 0x2040:
+		return true
+	}
+	return false
+}
+
+// xmlText return the text of scalar node, null is empty
+func xmlText(node *yaml.Node) string {
+	if node.ShortTag() == nullTag {
+		return ""
+	}
+	return node.Value
+}
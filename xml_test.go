@@ -0,0 +1,113 @@
+package yquery_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sixleaveakkm/yquery"
+)
+
+// language=xml
+var xmlData = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- legacy configuration -->
+<config version="2">
+  <name>legacy &amp; old</name>
+  <server port="8080">web</server>
+  <server port="5432">db</server>
+  <client>
+    <timeout>30</timeout>
+  </client>
+  <empty/>
+</config>
+`
+
+func TestUnmarshalXML(t *testing.T) {
+	asserts := assert.New(t)
+	yq, err := yquery.UnmarshalXML([]byte(xmlData))
+	asserts.NoError(err)
+	res, err := yq.Get("config.server[1].+@port")
+	asserts.NoError(err)
+	asserts.Equal("5432", res)
+	out, err := yq.Marshal()
+	asserts.NoError(err)
+	asserts.Equal(`config:
+    +@version: 2
+    name: legacy & old
+    server:
+        - +@port: 8080
+          +content: web
+        - +@port: 5432
+          +content: db
+    client:
+        timeout: 30
+    empty:
+`, string(out))
+
+	out, err = yq.MarshalXMLWith(yquery.XMLOptions{})
+	asserts.NoError(err)
+	asserts.Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<config version="2">
+  <name>legacy &amp; old</name>
+  <server port="8080">web</server>
+  <server port="5432">db</server>
+  <client>
+    <timeout>30</timeout>
+  </client>
+  <empty></empty>
+</config>
+`, string(out))
+
+	_, err = yquery.UnmarshalXML([]byte("<a><b></a>"))
+	asserts.Error(err)
+	_, err = yquery.UnmarshalXML([]byte("<!-- nothing -->"))
+	asserts.Error(err)
+}
+
+func TestXMLOptions(t *testing.T) {
+	asserts := assert.New(t)
+	options := yquery.XMLOptions{AttributePrefix: "_", TextKey: "_text", Sequences: []string{"config.client"}, Indent: 4}
+	yq, err := yquery.UnmarshalXML([]byte(xmlData), options)
+	asserts.NoError(err)
+	res, err := yq.Get("config.client[0].timeout")
+	asserts.NoError(err)
+	asserts.Equal("30", res)
+	res, _ = yq.Get("config.server[0]._text")
+	asserts.Equal("web", res)
+	out, err := yq.MarshalXMLWith(options)
+	asserts.NoError(err)
+	asserts.Contains(string(out), "\n    <server port=\"8080\">web</server>\n")
+}
+
+func TestMarshalXMLError(t *testing.T) {
+	asserts := assert.New(t)
+	testCases := []string{
+		"a: 1\nb: 2\n",
+		"- a\n",
+		"a:\n  b:\n    - [1]\n",
+		"a:\n  +@b: [1]\n",
+		"a:\n  +content:\n    b: 1\n",
+		"a b: 1\n",
+		"a:\n  c<d: 1\n",
+		"1a: 1\n",
+		"a:\n  +@x y: 1\n",
+		"a:\n  +@: 1\n",
+	}
+	for _, c := range testCases {
+		yq, _ := yquery.Unmarshal([]byte(c))
+		_, err := yq.MarshalXMLWith(yquery.XMLOptions{})
+		asserts.Error(err, c)
+	}
+
+	yq, _ := yquery.Unmarshal([]byte("base: &base\n  a: 1\nroot:\n  x: *base\n"))
+	yq.RootNode.Content = yq.RootNode.Content[2:]
+	out, err := yq.MarshalXMLWith(yquery.XMLOptions{})
+	asserts.NoError(err)
+	asserts.Contains(string(out), "<x>\n    <a>1</a>\n  </x>")
+
+	yq, _ = yquery.Unmarshal([]byte("a:\n  +@x.y: 1\n  b-c_d.é: 2\n"))
+	out, err = yq.MarshalXMLWith(yquery.XMLOptions{})
+	asserts.NoError(err)
+	asserts.Contains(string(out), `<a x.y="1">`)
+	asserts.Contains(string(out), "<b-c_d.é>2</b-c_d.é>")
+}
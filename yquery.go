@@ -13,9 +13,14 @@ import (
 const (
 	intTag   = "!!int"
 	strTag   = "!!str"
+	nullTag  = "!!null"
 	seqTag   = "!!seq"
 	mapTag   = "!!map"
 	mergeTag = "!!merge"
+	boolTag  = "!!bool"
+	floatTag = "!!float"
+
+	timestampTag = "!!timestamp"
 )
 
 // YQuery is the data struct hold necessary unmarshal data
@@ -24,52 +29,69 @@ const (
 type YQuery struct {
 	RootNode *yaml.Node
 
-	maxMergeInOneLayer int
+	history *history
+	// path is the file loaded by Load or saved by SaveAs
+	path string
+	// format is the format detected by Load or LoadReader, nil for default format of Marshal
+	format *format
+	// source is the data unmarshalled or last saved, used by MarshalMinimal
+	source []byte
+	// document holds comments of the document node, its content is always RootNode
+	document yaml.Node
+	// directives are the directives before the document start, e.g. "%YAML 1.1"
+	directives []string
+	// documentStart is set if the document starts with "---"
+	documentStart bool
+	// finished is set for the document of a committed or rolled back transaction, it could not be modified
+	finished bool
+	// generation is increased by each modification, a transaction could not be committed if it changes after Begin
+	generation int
 }
 
 // Unmarshal bytes data into a struct (Node) inside this package, return error if meets problem
-// Optionally you could override maximum number of merge struct directly in one node.
-// The default value will be 3. With should cover most case.
-// e.g.
-//     ...
-//      a:
-//        <<: *mergeFromSomewhere
-//        <<: *mergeFromSomewhereElse
-//     ...
-//
-// In the example above, the number of merge in one node is 2.
-// And in the example below, the number is 1.
-//  	a:
-//  	  <<: &a
-//  	     a1: foo
-//  	b:
-//  	  <<: &b
-//  	    <<: *a
-//  	    b1: bar
-//  	c:
-//  	  <<: &b
 // It use RootNode to store data, which type is *yaml.Node, comes from go-yaml.
+// RootNode is nil for empty data or data with only comments, Set creates the root node for it.
+// The root itself could not be addressed by a parser string, an empty parser string is an error for Get, Set and Delete.
+// Read or change a scalar root through RootNode directly, Set and Delete only reach items inside a mapping or sequence root,
+// and Set returns error for a scalar root.
+//
+// Deprecated parameter maxMerge used to limit the number of merge struct directly in one node.
+// There is no limit any more, the parameter is ignored and kept only for compatibility.
 func Unmarshal(in []byte, maxMerge ...int) (*YQuery, error) {
 	y := YQuery{}
-	if len(maxMerge) > 0 {
-		y.maxMergeInOneLayer = maxMerge[0]
-	} else {
-		y.maxMergeInOneLayer = 3
-	}
 
-	node := yaml.Node{}
-	err := yaml.Unmarshal(in, &node)
+	header, node, err := unmarshalDocument(in)
 	if err != nil {
 		return nil, err
 	}
+	y.source = append([]byte{}, in...)
+	y.directives = header.directives
+	y.documentStart = header.start
+	if len(node.Content) == 0 {
+		// empty document, go-yaml drops comments in it
+		y.document = yaml.Node{Kind: yaml.DocumentNode, HeadComment: commentLines(in)}
+		return &y, nil
+	}
 	y.RootNode = node.Content[0]
+	y.document = node
+	y.document.Content = nil
 	return &y, nil
 }
 
+// New create an empty mapping document
+//     yq := yquery.New()
+//     _ = yq.Set("a", "1")
+func New() *YQuery {
+	return &YQuery{
+		RootNode: &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag},
+		document: yaml.Node{Kind: yaml.DocumentNode},
+	}
+}
+
 // Marshal struct, return bytes data if no error
-// Wrapper of gopkg.in/yaml.v3.
+// Wrapper of gopkg.in/yaml.v3. Comments, directives and the document start "---" of the document are written as well.
 func (y *YQuery) Marshal() ([]byte, error) {
-	return yaml.Marshal(y.RootNode)
+	return y.encode(defaultFormat)
 }
 
 // Get return the parsed data string of the parser if no error
@@ -129,7 +151,7 @@ func (y *YQuery) getNodeString(parser string, raw bool, customDelimiter ...strin
 	if node.Value != "" {
 		return node.Value, nil
 	}
-	str, err := yaml.Marshal(&node)
+	str, err := yaml.Marshal(untagMergeKeys(node))
 	if err != nil {
 		return "", err
 	}
@@ -144,9 +166,12 @@ func (y *YQuery) GetNode(parser string, raw bool, config ...Config) (*yaml.Node,
 		return y.RootNode, err
 	}
 	slices := getParserSlice(parser, delimiter)
+	if y.RootNode == nil {
+		return nil, fmt.Errorf("cannot find item %s, the document is empty", parser)
+	}
 	// make a copy of root. Prevent modify
 	node := y.RootNode
-	result := y.parseNode(slices, delimiter, node, 0, false, parseParameter{
+	result := y.parseNode(slices, delimiter, node, 0, parseParameter{
 		getParameter: getParameter{
 			IsRaw: raw,
 		},
@@ -156,25 +181,39 @@ func (y *YQuery) GetNode(parser string, raw bool, config ...Config) (*yaml.Node,
 
 // Set the value of responding node
 // Cannot set value inside anchor reference's, and not able to override sub item of a merge item.
+// For an empty document, the root node is created as mapping, or sequence if the parser string starts with index, e.g. "[0]".
+// It returns error if the root is a scalar, which could not be addressed, change RootNode directly instead.
 func (y *YQuery) Set(parser string, value string, config ...Config) error {
-	if len(config) == 0 {
-		config = append(config, Config{})
-	}
-	delimiter, err := getDelimiter(config)
-	if err != nil {
-		return err
-	}
-	slices := getParserSlice(parser, delimiter)
-	result := y.parseNode(slices, delimiter, y.RootNode, 0, true, parseParameter{
-		setParameter: setParameter{
-			Config: config[0],
-			Value:  value,
-		},
+	return y.modify(Operation{Name: "Set", Paths: []string{parser}}, func() error {
+		if len(config) == 0 {
+			config = append(config, Config{})
+		}
+		delimiter, err := getDelimiter(config)
+		if err != nil {
+			return err
+		}
+		slices := getParserSlice(parser, delimiter)
+		if y.RootNode != nil && y.RootNode.Kind != yaml.MappingNode && y.RootNode.Kind != yaml.SequenceNode {
+			return fmt.Errorf("cannot set item %s, the root is not a mapping or sequence", parser)
+		}
+		empty := y.RootNode == nil
+		if empty {
+			y.RootNode = newContainerNode(slices[0])
+		}
+		result := y.setNode(slices, delimiter, y.RootNode, 0, parseParameter{
+			setParameter: setParameter{
+				Config: config[0],
+				Value:  value,
+			},
+		})
+		if result.Err != nil {
+			if empty {
+				y.RootNode = nil
+			}
+			return result.Err
+		}
+		return nil
 	})
-	if result.Err != nil {
-		return result.Err
-	}
-	return nil
 }
 
 // Config is the optional parameter for set
@@ -207,10 +246,50 @@ type Config struct {
 	// If you trust yourself knowing there is no other value in the structure,
 	// you could use this parameter to force set the value.
 	ForceInMerge bool
+	// MaterializeMerge could be set to true to override a element inside a merge node without data loss.
+	// Instead of creating a new empty node, the node comes from the merge is copied into current node before set.
+	// For the example above, set "o.a.b" to 444 with this parameter results
+	//     o:
+	//       <<: *mergeA
+	//          a:
+	//             b: 333
+	//             c: testValue
+	//          d: otherValue
+	//       a:
+	//         b: 444
+	//         c: testValue
+	//
+	// Only "a" is copied, "d" is still inherited from the merge.
+	// It takes precedence over ForceInMerge.
+	MaterializeMerge bool
 	// Recursive could be set to true to create missing node in the middle of the path of your parser string
 	// Or yquery will return an error when it could not found the element.
 	// You don't need to set this to true if only the last element of your parser string is not exist.
+	// e.g. `Set("a.b[0].c", "value", Config{Recursive: true})` on an empty document results
+	//     a:
+	//       b:
+	//         - c: value
+	//
+	// A scalar item in the middle of the path is replaced by the created node.
 	Recursive bool
+	// PadSequence could be set to true to set a sequence item whose index is larger than the length of the sequence
+	// Items between are filled with null, up to index 10000.
+	// Or yquery will return an error, only the index equals to the length (append) is allowed.
+	PadSequence bool
+	// Style is the style of the value set
+	// By default (KeepStyle), when an existing scalar is replaced, its quote or block style is kept,
+	// e.g. set `a: "foo"` to bar results `a: "bar"`.
+	// Anchor, comments and explicit tag of the replaced value are always kept.
+	Style ValueStyle
+	// Tag force the tag of the value set, e.g. "!!str", "!!int", or custom tag "!foo"
+	// By default the tag is inferred from value, e.g. `0123` is an int, you can set it to "!!str" to keep it a string.
+	// An error is returned when value cannot be represented with a standard tag, e.g. "abc" as "!!int".
+	Tag string
+	// Comment is written to the item set, empty ones are ignored
+	// e.g. set "a" with Comment{KeyHead: "comment of a", Line: "line comment"} results
+	//     # comment of a
+	//     a: value # line comment
+	Comment Comment
 }
 
 type setParameter struct {
@@ -234,29 +313,11 @@ type parseResult struct {
 	Err  error
 }
 
-func (y *YQuery) setNodeValue(key string, value string) parseResult {
-	// todo: handler complex value
-	var node yaml.Node
-	err := yaml.Unmarshal([]byte(key+": "+value), &node)
-	if err != nil {
-		// not one line
-		// change to new line and padding
-		value := "\n  " + strings.Replace(value, "\n", "\n  ", -1)
-		err = yaml.Unmarshal([]byte(key+": "+value), &node)
-	}
-	return parseResult{&node, err}
-}
-
-func (y *YQuery) parseNode(slices []string, delimiter string, currentNode *yaml.Node, i int, isSet bool, parameter parseParameter) parseResult {
+func (y *YQuery) parseNode(slices []string, delimiter string, currentNode *yaml.Node, i int, parameter parseParameter) parseResult {
 	var e error
-	ch := make(chan parseResult, y.maxMergeInOneLayer)
 
 	if i == len(slices) {
 		// leaf node
-		if isSet {
-			return parseResult{currentNode, fmt.Errorf("code should not enter here")}
-		}
-		// get
 		node := *currentNode
 		// anchor use, change anchor value to *value when get raw
 		if node.Alias != nil {
@@ -275,58 +336,8 @@ func (y *YQuery) parseNode(slices []string, delimiter string, currentNode *yaml.
 		return parseResult{&node, nil}
 	}
 
-	if isSet && i == len(slices)-1 {
-		// set and node is leaf node, create map
-		if currentNode.Alias != nil {
-			return parseResult{currentNode, fmt.Errorf("the item '%s' reaches an anchor reference. You can not modify value from anchor reference", strings.Join(slices[:i], delimiter))}
-		}
-		if slices[i] == "" {
-			return parseResult{currentNode, fmt.Errorf("cannot parse on blank item '%s'", strings.Join(slices[:i], delimiter))}
-		}
-		result := y.setNodeValue(slices[i], parameter.Value)
-		keyNode := result.Node.Content[0].Content[0]
-		valueNode := result.Node.Content[0].Content[1]
-		switch currentNode.Tag {
-		case seqTag:
-			index, err := getSequenceNum(slices[i])
-			if err != nil {
-				return parseResult{currentNode, err}
-			}
-			if index > len(currentNode.Content) {
-				return parseResult{currentNode, fmt.Errorf("cannot set item %s with index %d while it only has %d item", strings.Join(slices[:i], delimiter), index, len(currentNode.Content))}
-			}
-			if index < len(currentNode.Content) {
-				currentNode.Content[index] = valueNode
-			} else {
-				// add new item
-				currentNode.Content = append(currentNode.Content, valueNode)
-			}
-			return parseResult{currentNode, nil}
-		case mapTag:
-			for index, content := range currentNode.Content {
-				if index%2 == 1 {
-					continue
-				}
-
-				if content.Value == slices[i] {
-					currentNode.Content[index+1] = valueNode
-					return parseResult{currentNode, nil}
-				}
-			}
-			// key not exists
-			currentNode.Content = append(currentNode.Content, keyNode, valueNode)
-			return parseResult{currentNode, nil}
-		default:
-			parameter.ParentNode.Content[parameter.Index] = result.Node.Content[0]
-			return parseResult{currentNode, nil}
-		}
-	}
-
-	// anchor use, return error
+	// anchor use
 	if currentNode.Alias != nil {
-		if isSet {
-			return parseResult{currentNode, fmt.Errorf("the item '%s' reaches an anchor reference. You cannot modify value from anchor reference", strings.Join(slices[:i], delimiter))}
-		}
 		currentNode = currentNode.Alias
 	}
 
@@ -341,95 +352,25 @@ func (y *YQuery) parseNode(slices []string, delimiter string, currentNode *yaml.
 		if index >= len(currentNode.Content) {
 			return parseResult{currentNode, fmt.Errorf("the item %s cannot found. Index out of range", strings.Join(slices[:i], delimiter))}
 		}
-		parameter.ParentNode = currentNode
-		parameter.Index = index
-		return y.parseNode(slices, delimiter, currentNode.Content[index], i+1, isSet, parameter)
+		return y.parseNode(slices, delimiter, currentNode.Content[index], i+1, parameter)
 	case mapTag:
 		for index, content := range currentNode.Content {
 			if index%2 == 1 {
 				continue
 			}
 
-			if content.Tag == mergeTag {
-				if !isSet {
-					ch <- y.parseNode(slices, delimiter, currentNode.Content[index+1], i, isSet, parameter)
-				}
-			}
-			if content.Value == slices[i] {
-				parameter.ParentNode = currentNode
-				parameter.Index = index + 1
-				return y.parseNode(slices, delimiter, currentNode.Content[index+1], i+1, isSet, parameter)
+			if content.Tag != mergeTag && content.Value == slices[i] {
+				return y.parseNode(slices, delimiter, currentNode.Content[index+1], i+1, parameter)
 			}
 		}
+		// node not found in element, but found in merge
+		if inherited := findInMerge(currentNode, slices[i]); inherited != nil {
+			return y.parseNode(slices, delimiter, inherited, i+1, parameter)
+		}
 		e = fmt.Errorf("cannot find item %s", strings.Join(slices[:i], delimiter))
 	default:
-		if isSet {
-			// literal node need to change to struct
-			return y.setWhenStructNotFound(slices, delimiter, currentNode, i, parameter)
-		}
 		e = fmt.Errorf("unable continue to parse item %s, get value: %s",
 			strings.Join(slices[:i], delimiter), currentNode.Value)
 	}
-	close(ch)
-	if e != nil {
-		// get, node found in element, but found in merge. return.
-		var resArr []parseResult
-		for i := 0; i < y.maxMergeInOneLayer; i++ {
-			res, ok := <-ch
-			if !ok {
-				continue
-			}
-			if res.Err == nil {
-				resArr = append(resArr, res)
-			}
-		}
-		if len(resArr) > 0 {
-			return resArr[len(resArr)-1]
-		}
-	}
 	return parseResult{currentNode, e}
 }
-
-func (y *YQuery) setWhenStructNotFound(slices []string, delimiter string, currentNode *yaml.Node, i int, parameter parseParameter) parseResult {
-	// literal node need to change to struct
-	seqID, err := getSequenceNum(slices[i+1])
-	if err == nil && seqID != 0 {
-		return parseResult{currentNode, fmt.Errorf("can not create new sequence with only %d's item", seqID)}
-	}
-	if i < len(slices)-1 {
-		// remaining more then one level
-		if !parameter.Recursive {
-			return parseResult{currentNode, fmt.Errorf("internal item '%s' not exists", strings.Join(slices[:i+1], delimiter))}
-		}
-		var contentHolderNode yaml.Node
-		if seqID == 0 {
-			contentHolderNode = yaml.Node{
-				Kind:    yaml.SequenceNode,
-				Tag:     seqTag,
-				Content: nil,
-			}
-		} else {
-			contentHolderNode = yaml.Node{
-				Kind: yaml.MappingNode,
-				Tag:  mapTag,
-			}
-		}
-		parameter.ParentNode.Content[parameter.Index] = &yaml.Node{
-			Kind:  yaml.MappingNode,
-			Style: 0,
-			Tag:   mapTag,
-			Content: []*yaml.Node{
-				&yaml.Node{
-					Kind:  yaml.ScalarNode,
-					Tag:   strTag,
-					Value: slices[i+1],
-				},
-				&contentHolderNode,
-			},
-		}
-		return y.parseNode(slices, delimiter, currentNode, i+1, true, parameter)
-	}
-	result := y.setNodeValue(slices[i], parameter.Value)
-	parameter.ParentNode.Content[parameter.Index] = result.Node.Content[0]
-	return parseResult{currentNode, nil}
-}
@@ -225,14 +225,14 @@ func TestStrangeDelimiterError(t *testing.T) {
 	asserts.Error(err)
 }
 
-// func TestRecursiveSet(t *testing.T) {
-// 	asserts := assert.New(t)
-// 	testCase := casePair{"newA.newB.newC", "new value"}
-// 	err := yq.Set(testCase.Parser, testCase.Value, yquery.Config{
-// 		Recursive: true,
-// 	})
-// 	asserts.NoError(err)
-// 	res, _ := yq.Get(testCase.Parser)
-// 	asserts.Equal(testCase.Value, res)
-//
-// }
+func TestRecursiveSet(t *testing.T) {
+	asserts := assert.New(t)
+	testCase := casePair{"newA.newB.newC", "new value"}
+	err := yq.Set(testCase.Parser, testCase.Value, yquery.Config{
+		Recursive: true,
+	})
+	asserts.NoError(err)
+	res, _ := yq.Get(testCase.Parser)
+	asserts.Equal(testCase.Value, res)
+
+}